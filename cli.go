@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/d1manpro/nginx-proxy-api/client"
+)
+
+const cliUsage = `Usage: npapi <command> [flags] [args]
+
+Commands:
+  serve                          run the API server (default)
+  proxy add <domain> <target>    add a proxy (-pending for custom domains)
+  proxy remove <domain>          remove a proxy
+  proxy list                     list proxies
+  proxy show <domain>            show one proxy with its health
+  cert list                      list certificates
+  cert renew <name>              renew a certificate (-force to renew early)
+  token create <name>            create an API token (-scopes, -domains, ...)
+  config validate                check the config and template (-config, -template)
+
+Common flags:
+  -server URL   API address (env NPA_SERVER, default http://127.0.0.1:8080)
+  -token TOKEN  API token (env NPA_TOKEN)
+  -local        work on this host's nginx, certbot and Cloudflare directly,
+                using the server config (NPA_CONFIG)
+  -o FORMAT     output format: table or json
+`
+
+// cliOptions are the flags shared by all client commands.
+type cliOptions struct {
+	server string
+	token  string
+	local  bool
+	output string
+}
+
+type command func(args []string) error
+
+var commands = map[string]command{
+	"proxy add":    proxyAdd,
+	"proxy remove": proxyRemove,
+	"proxy list":   proxyList,
+	"proxy show":   proxyShow,
+	"cert list":    certList,
+	"cert renew":   certRenew,
+	"token create": tokenCreate,
+
+	"config validate": configValidate,
+}
+
+func runCommand(args []string) int {
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, cliUsage)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]+" "+args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", strings.Join(args[:2], " "), cliUsage)
+		return 2
+	}
+
+	if err := cmd(args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *cliOptions) {
+	opts := &cliOptions{}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.server, "server", envOr("NPA_SERVER", "http://127.0.0.1:8080"), "API address")
+	fs.StringVar(&opts.token, "token", os.Getenv("NPA_TOKEN"), "API token")
+	fs.BoolVar(&opts.local, "local", false, "work on the local stack instead of a running server")
+	fs.StringVar(&opts.output, "o", "table", "output format: table or json")
+	return fs, opts
+}
+
+// parse parses flags, which may come before or after the positional
+// arguments, and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) != len(names) {
+		return nil, fmt.Errorf("%s expects %d argument(s): %s", fs.Name(), len(names), strings.Join(names, " "))
+	}
+	return positional, nil
+}
+
+// client returns an API client for the options and a function that releases
+// what local mode opened.
+func (o *cliOptions) client() (*client.Client, func(), error) {
+	if o.local {
+		return localClient()
+	}
+	return client.New(o.server, client.WithToken(o.token)), func() {}, nil
+}
+
+// print writes v as JSON, or as a table with the given header and rows.
+func (o *cliOptions) print(v any, header []string, rows [][]string) error {
+	switch o.output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", o.output)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+func certList(args []string) error {
+	fs, opts := newFlagSet("cert list")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	c, done, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	certs, err := c.ListCerts(context.Background())
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(certs))
+	for _, cert := range certs {
+		rows = append(rows, []string{
+			cert.Name,
+			strings.Join(cert.Domains, ","),
+			cert.NotAfter.Format("2006-01-02"),
+			strconv.Itoa(cert.DaysLeft),
+		})
+	}
+	return opts.print(certs, []string{"NAME", "DOMAINS", "EXPIRES", "DAYS LEFT"}, rows)
+}
+
+func certRenew(args []string) error {
+	fs, opts := newFlagSet("cert renew")
+	force := fs.Bool("force", false, "renew even if the certificate is not close to expiry")
+	args, err := parse(fs, args, "<name>")
+	if err != nil {
+		return err
+	}
+
+	c, done, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := c.RenewCert(context.Background(), args[0], *force); err != nil {
+		return err
+	}
+	return opts.print(map[string]string{"status": "renewed"}, []string{"NAME", "STATUS"}, [][]string{{args[0], "renewed"}})
+}
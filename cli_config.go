@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+)
+
+// configReport is the result of config validate.
+type configReport struct {
+	Config     string            `json:"config"`
+	Template   string            `json:"template"`
+	EnvFile    string            `json:"env_file"`
+	Valid      bool              `json:"valid"`
+	Problems   []string          `json:"problems,omitempty"`
+	Precedence []string          `json:"precedence"`
+	Overrides  []config.Override `json:"overrides,omitempty"`
+}
+
+func configValidate(args []string) error {
+	cfgPath, tmplPath := config.Paths()
+
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.StringVar(&cfgPath, "config", cfgPath, "config file")
+	fs.StringVar(&tmplPath, "template", tmplPath, "nginx template")
+	output := fs.String("o", "table", "output format: table or json")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	opts := &cliOptions{output: *output}
+
+	overrides, err := config.Overrides()
+	if err != nil {
+		return err
+	}
+
+	report := configReport{
+		Config:     cfgPath,
+		Template:   tmplPath,
+		EnvFile:    config.EnvFile(),
+		Valid:      true,
+		Precedence: config.Precedence,
+		Overrides:  overrides,
+	}
+	_, err = config.LoadFrom(cfgPath, tmplPath)
+	var verr *config.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		report.Valid = false
+		report.Problems = verr.Problems
+	case err != nil:
+		return err
+	}
+
+	rows := make([][]string, 0, len(report.Problems))
+	for _, problem := range report.Problems {
+		rows = append(rows, []string{problem})
+	}
+	if report.Valid {
+		rows = append(rows, []string{fmt.Sprintf("%s and %s are valid", cfgPath, tmplPath)})
+	}
+	if err := opts.print(report, []string{"PROBLEM"}, rows); err != nil {
+		return err
+	}
+	if opts.output == "table" {
+		printSources(report)
+	}
+
+	if !report.Valid {
+		return fmt.Errorf("config has %d problem(s)", len(report.Problems))
+	}
+	return nil
+}
+
+// printSources shows where the values come from, so that a key set in both
+// config.yml and the environment is not a surprise.
+func printSources(report configReport) {
+	fmt.Println("\nValues are taken from, highest precedence first:")
+	for i, source := range report.Precedence {
+		fmt.Printf("  %d. %s\n", i+1, source)
+	}
+	fmt.Printf(".env file: %s\n", report.EnvFile)
+
+	if len(report.Overrides) == 0 {
+		fmt.Println("\nNo keys are set from the environment.")
+		return
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tSOURCE")
+	for _, o := range report.Overrides {
+		fmt.Fprintf(w, "%s\t%s\n", o.Key, o.Source)
+	}
+	w.Flush()
+}
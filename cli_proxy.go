@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/d1manpro/nginx-proxy-api/client"
+)
+
+func proxyAdd(args []string) error {
+	fs, opts := newFlagSet("proxy add")
+	pending := fs.Bool("pending", false, "wait for the custom domain's DNS before provisioning")
+	args, err := parse(fs, args, "<domain>", "<target>")
+	if err != nil {
+		return err
+	}
+
+	c, done, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	resp, err := c.AddProxy(context.Background(), client.AddProxyReq{
+		Domain:  args[0],
+		Target:  args[1],
+		Pending: *pending,
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{{args[0], resp.Status, strings.Join(resp.Problems, "; ")}}
+	if err := opts.print(resp, []string{"DOMAIN", "STATUS", "PROBLEMS"}, rows); err != nil {
+		return err
+	}
+	if opts.output == "table" && len(resp.Expected) > 0 {
+		fmt.Println("\nExpected DNS records:")
+		for _, r := range resp.Expected {
+			fmt.Printf("  %s %s %s\n", r.Type, r.Name, r.Value)
+		}
+	}
+	return nil
+}
+
+func proxyRemove(args []string) error {
+	fs, opts := newFlagSet("proxy remove")
+	args, err := parse(fs, args, "<domain>")
+	if err != nil {
+		return err
+	}
+
+	c, done, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := c.RemoveProxy(context.Background(), args[0]); err != nil {
+		return err
+	}
+	return opts.print(map[string]string{"status": "removed"}, []string{"DOMAIN", "STATUS"}, [][]string{{args[0], "removed"}})
+}
+
+func proxyList(args []string) error {
+	fs, opts := newFlagSet("proxy list")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	c, done, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	proxies, err := c.ListProxies(context.Background())
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(proxies))
+	for _, p := range proxies {
+		rows = append(rows, proxyRow(p))
+	}
+	return opts.print(proxies, proxyHeader, rows)
+}
+
+func proxyShow(args []string) error {
+	fs, opts := newFlagSet("proxy show")
+	args, err := parse(fs, args, "<domain>")
+	if err != nil {
+		return err
+	}
+
+	c, done, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	p, ok, err := c.GetProxy(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("proxy %s not found", args[0])
+	}
+	return opts.print(p, proxyHeader, [][]string{proxyRow(p)})
+}
+
+var proxyHeader = []string{"DOMAIN", "TARGET", "CERT", "STATE", "HEALTH", "CREATED"}
+
+func proxyRow(p client.Proxy) []string {
+	state := "enabled"
+	switch {
+	case p.Pending:
+		state = "pending"
+	case p.Disabled:
+		state = "disabled"
+	case p.Maintenance:
+		state = "maintenance"
+	}
+
+	health := "-"
+	if p.Health != nil {
+		health = "healthy"
+		if !p.Health.Healthy {
+			health = "unhealthy (" + strconv.Itoa(p.Health.Failures) + ")"
+		}
+	}
+
+	return []string{p.Domain, p.Target, p.CertDomain, state, health, p.CreatedAt.Format("2006-01-02 15:04")}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/client"
+)
+
+func tokenCreate(args []string) error {
+	fs, opts := newFlagSet("token create")
+	scopes := fs.String("scopes", "", "comma-separated scopes, e.g. proxy:write,cert:manage")
+	domains := fs.String("domains", "", "comma-separated domain patterns the token is limited to")
+	scheme := fs.String("scheme", "", "bearer (default) or hmac")
+	expires := fs.Duration("expires", 0, "lifetime of the token, e.g. 720h; 0 never expires")
+	args, err := parse(fs, args, "<name>")
+	if err != nil {
+		return err
+	}
+
+	req := client.CreateTokenReq{
+		Name:    args[0],
+		Scheme:  *scheme,
+		Scopes:  splitList(*scopes),
+		Domains: splitList(*domains),
+	}
+	if *expires > 0 {
+		t := time.Now().Add(*expires).UTC()
+		req.ExpiresAt = &t
+	}
+
+	c, done, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	token, err := c.CreateToken(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := "never"
+	if token.ExpiresAt != nil {
+		expiresAt = token.ExpiresAt.Format(time.RFC3339)
+	}
+	rows := [][]string{{token.Name, token.Scheme, strings.Join(token.Scopes, ","), expiresAt, token.Token}}
+	return opts.print(token, []string{"NAME", "SCHEME", "SCOPES", "EXPIRES", "TOKEN"}, rows)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
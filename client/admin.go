@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func (c *Client) ListAudit(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	query := url.Values{}
+	if !f.From.IsZero() {
+		query.Set("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		query.Set("to", f.To.Format(time.RFC3339))
+	}
+	if f.Domain != "" {
+		query.Set("domain", f.Domain)
+	}
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+
+	var entries []AuditEntry
+	err := c.do(ctx, "GET", "/audit", query, nil, &entries)
+	return entries, err
+}
+
+func (c *Client) VerifyAudit(ctx context.Context) (AuditVerify, error) {
+	var result AuditVerify
+	err := c.do(ctx, "GET", "/audit/verify", nil, nil, &result)
+	return result, err
+}
+
+func (c *Client) LogLevels(ctx context.Context) (LogLevels, error) {
+	var levels LogLevels
+	err := c.do(ctx, "GET", "/log/level", nil, nil, &levels)
+	return levels, err
+}
+
+// SetLogLevel changes the root level, or a subsystem's level when subsystem
+// is set.
+func (c *Client) SetLogLevel(ctx context.Context, subsystem, level string) (LogLevels, error) {
+	var levels LogLevels
+	err := c.do(ctx, "PUT", "/log/level", nil, map[string]string{"subsystem": subsystem, "level": level}, &levels)
+	return levels, err
+}
+
+// Ready reports the readiness checks. A failing check is not an error; the
+// result is returned with Status "fail".
+func (c *Client) Ready(ctx context.Context) (Readiness, error) {
+	status, header, data, err := c.send(ctx, "GET", "/readyz", nil, nil)
+	if err != nil {
+		return Readiness{}, err
+	}
+	if status != http.StatusOK && status != http.StatusServiceUnavailable {
+		return Readiness{}, newError(status, header, data)
+	}
+
+	var ready Readiness
+	err = json.Unmarshal(data, &ready)
+	return ready, err
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+func (c *Client) ListCerts(ctx context.Context) ([]Cert, error) {
+	var certs []Cert
+	err := c.do(ctx, "GET", "/certs", nil, nil, &certs)
+	return certs, err
+}
+
+// RenewCert renews a certificate; with force it is renewed even if it is
+// not due yet.
+func (c *Client) RenewCert(ctx context.Context, name string, force bool) error {
+	var query url.Values
+	if force {
+		query = url.Values{"force": {"true"}}
+	}
+	return c.do(ctx, "POST", "/certs/"+url.PathEscape(name)+"/renew", query, nil, nil)
+}
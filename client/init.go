@@ -0,0 +1,153 @@
+// Package client is a typed Go client for the nginx-proxy-api HTTP API.
+//
+//	c := client.New("https://npa.example.com", client.WithToken(token))
+//	proxies, err := c.ListProxies(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/signature"
+)
+
+type Client struct {
+	baseURL string
+	http    *http.Client
+
+	token      string
+	hmacName   string
+	hmacSecret string
+}
+
+type Option func(*Client)
+
+// WithToken authenticates with a bearer token (API token or OIDC JWT).
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHMAC signs every request with an HMAC token instead of sending it.
+func WithHMAC(name, secret string) Option {
+	return func(c *Client) {
+		c.hmacName = name
+		c.hmacSecret = secret
+	}
+}
+
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 5 * time.Minute},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do sends the request and decodes a JSON response into out. Error
+// responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	status, header, data, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+
+	if status >= http.StatusBadRequest {
+		return newError(status, header, data)
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, in any) (int, http.Header, []byte, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	uri := path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+uri, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req, uri, body)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, resp.Header, data, nil
+}
+
+func newError(status int, header http.Header, data []byte) *Error {
+	var envelope struct {
+		Error *Error `json:"error"`
+	}
+	if json.Unmarshal(data, &envelope) == nil && envelope.Error != nil {
+		envelope.Error.StatusCode = status
+		return envelope.Error
+	}
+
+	return &Error{
+		StatusCode: status,
+		Message:    strings.TrimSpace(string(data)),
+		RequestID:  header.Get("X-Request-ID"),
+	}
+}
+
+func (c *Client) authorize(req *http.Request, uri string, body []byte) {
+	switch {
+	case c.hmacSecret != "":
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		nonce := make([]byte, 16)
+		rand.Read(nonce)
+		nonceHex := hex.EncodeToString(nonce)
+
+		sig := signature.Sign(c.hmacSecret, signature.StringToSign(req.Method, uri, timestamp, nonceHex, body))
+		req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s, Signature=%s", signature.Scheme, c.hmacName, sig))
+		req.Header.Set(signature.HeaderTimestamp, timestamp)
+		req.Header.Set(signature.HeaderNonce, nonceHex)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Step       string `json:"step,omitempty"`
+	RequestID  string `json:"request_id"`
+	Details    any    `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("npapi: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	if e.Step != "" {
+		msg += " (step " + e.Step + ")"
+	}
+	if e.RequestID != "" {
+		msg += " [request " + e.RequestID + "]"
+	}
+	return msg
+}
+
+type AddProxyReq struct {
+	Domain  string `json:"domain"`
+	Target  string `json:"target"`
+	Pending bool   `json:"pending,omitempty"`
+}
+
+// AddProxyResp is "created", or "pending" with the DNS records a custom
+// domain still needs.
+type AddProxyResp struct {
+	Status   string      `json:"status"`
+	Problems []string    `json:"problems,omitempty"`
+	Expected []DNSRecord `json:"expected,omitempty"`
+}
+
+type Proxy struct {
+	Domain          string        `json:"domain"`
+	Target          string        `json:"target"`
+	CertDomain      string        `json:"cert_domain"`
+	ZoneID          string        `json:"zone_id,omitempty"`
+	DNSRecords      []string      `json:"dns_records,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	CreatedBy       string        `json:"created_by,omitempty"`
+	Pending         bool          `json:"pending,omitempty"`
+	Provisioning    bool          `json:"provisioning,omitempty"`
+	Disabled        bool          `json:"disabled,omitempty"`
+	Maintenance     bool          `json:"maintenance"`
+	AutoMaintenance bool          `json:"auto_maintenance,omitempty"`
+	Enabled         bool          `json:"enabled"`
+	Health          *HealthStatus `json:"health,omitempty"`
+}
+
+type HealthStatus struct {
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Failures  int       `json:"consecutive_failures"`
+}
+
+type DNSRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type DomainCheck struct {
+	Domain    string      `json:"domain"`
+	OK        bool        `json:"ok"`
+	Addresses []string    `json:"addresses"`
+	CNAME     string      `json:"cname,omitempty"`
+	Problems  []string    `json:"problems,omitempty"`
+	Expected  []DNSRecord `json:"expected"`
+}
+
+type Limits struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	MaxProxies        int `json:"max_proxies,omitempty"`
+	MaxCertsPerWeek   int `json:"max_certs_per_week,omitempty"`
+}
+
+type Cert struct {
+	Name     string    `json:"name"`
+	Domains  []string  `json:"domains"`
+	NotAfter time.Time `json:"not_after"`
+	DaysLeft int       `json:"days_left"`
+}
+
+type CreateTokenReq struct {
+	Name      string     `json:"name"`
+	Scheme    string     `json:"scheme,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	Domains   []string   `json:"domains,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Limits    *Limits    `json:"limits,omitempty"`
+}
+
+// Token is an API token. Token is only set in the response to CreateToken.
+type Token struct {
+	Name      string     `json:"name"`
+	Token     string     `json:"token,omitempty"`
+	Scheme    string     `json:"scheme"`
+	Scopes    []string   `json:"scopes"`
+	Domains   []string   `json:"domains,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+	Limits    *Limits    `json:"limits,omitempty"`
+}
+
+type Usage struct {
+	Identity          string `json:"identity"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	Proxies           int    `json:"proxies"`
+	MaxProxies        int    `json:"max_proxies"`
+	CertsThisWeek     int    `json:"certs_this_week"`
+	MaxCertsPerWeek   int    `json:"max_certs_per_week"`
+}
+
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	IP       string    `json:"ip"`
+	Op       string    `json:"op"`
+	Domain   string    `json:"domain,omitempty"`
+	Target   string    `json:"target,omitempty"`
+	Before   any       `json:"before,omitempty"`
+	After    any       `json:"after,omitempty"`
+	Status   int       `json:"status"`
+	Outcome  string    `json:"outcome"`
+	Reason   string    `json:"reason,omitempty"`
+	PrevHash string    `json:"prev_hash,omitempty"`
+	Hash     string    `json:"hash,omitempty"`
+}
+
+// AuditFilter narrows ListAudit; zero fields are not sent.
+type AuditFilter struct {
+	From   time.Time
+	To     time.Time
+	Domain string
+	Limit  int
+}
+
+type AuditVerify struct {
+	Valid   bool `json:"valid"`
+	Entries int  `json:"entries,omitempty"`
+	Line    int  `json:"line,omitempty"`
+}
+
+type LogLevels struct {
+	Level      string            `json:"level"`
+	Subsystems map[string]string `json:"subsystems"`
+}
+
+type Readiness struct {
+	Status string                `json:"status"`
+	Checks map[string]CheckState `json:"checks"`
+}
+
+type CheckState struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/d1manpro/nginx-proxy-api/internal/handler"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
+	"github.com/d1manpro/nginx-proxy-api/internal/logging"
+)
+
+// TestModelsMatchServer checks that every client type carries exactly the
+// fields of the server type it mirrors, in both directions.
+func TestModelsMatchServer(t *testing.T) {
+	pairs := []struct {
+		name           string
+		server, client any
+	}{
+		{"proxy", &handler.ProxyResp{}, &Proxy{}},
+		{"health", &health.Status{}, &HealthStatus{}},
+		{"dns record", &dnscheck.Record{}, &DNSRecord{}},
+		{"domain check", &dnscheck.Result{}, &DomainCheck{}},
+		{"pending", &handler.PendingResp{}, &AddProxyResp{}},
+		{"add proxy request", &handler.AddDomainReq{}, &AddProxyReq{}},
+		{"cert", &certbot.Cert{}, &Cert{}},
+		{"create token request", &handler.CreateTokenReq{}, &CreateTokenReq{}},
+		{"token", &handler.TokenResp{}, &Token{}},
+		{"usage", &limits.Usage{}, &Usage{}},
+		{"audit entry", &audit.Entry{}, &AuditEntry{}},
+		{"audit verify", &handler.AuditVerifyResp{}, &AuditVerify{}},
+		{"log levels", &logging.Levels{}, &LogLevels{}},
+		{"readiness", &handler.ReadyResp{}, &Readiness{}},
+		{"error", &apierr.Error{}, &Error{}},
+	}
+
+	for _, p := range pairs {
+		t.Run(p.name, func(t *testing.T) {
+			roundTrip(t, p.server, p.client)
+			roundTrip(t, p.client, p.server)
+		})
+	}
+}
+
+// roundTrip fills every field of from, decodes its JSON into a fresh value
+// of to's type, and expects the same JSON back.
+func roundTrip(t *testing.T, from, to any) {
+	t.Helper()
+
+	fill(reflect.ValueOf(from).Elem())
+	want, err := json.Marshal(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	into := reflect.New(reflect.TypeOf(to).Elem()).Interface()
+	dec := json.NewDecoder(bytes.NewReader(want))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(into); err != nil {
+		t.Fatalf("%T -> %T: %v", from, into, err)
+	}
+
+	got, err := json.Marshal(into)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wantAny, gotAny any
+	json.Unmarshal(want, &wantAny)
+	json.Unmarshal(got, &gotAny)
+	if !reflect.DeepEqual(wantAny, gotAny) {
+		t.Errorf("%T -> %T changed the JSON:\n got  %s\n want %s", from, into, got, want)
+	}
+}
+
+// fill sets every exported field to a non-zero value, so omitempty does not
+// hide fields from the comparison.
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+			return
+		}
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		key := reflect.New(v.Type().Key()).Elem()
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fill(key)
+		fill(elem)
+		v.SetMapIndex(key, elem)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf("any"))
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(1)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+func (c *Client) ListProxies(ctx context.Context) ([]Proxy, error) {
+	var proxies []Proxy
+	err := c.do(ctx, "GET", "/proxy", nil, nil, &proxies)
+	return proxies, err
+}
+
+// GetProxy finds one proxy in the list visible to the caller. ok is false if
+// there is no such proxy.
+func (c *Client) GetProxy(ctx context.Context, domain string) (proxy Proxy, ok bool, err error) {
+	proxies, err := c.ListProxies(ctx)
+	if err != nil {
+		return Proxy{}, false, err
+	}
+	for _, p := range proxies {
+		if p.Domain == domain {
+			return p, true, nil
+		}
+	}
+	return Proxy{}, false, nil
+}
+
+func (c *Client) AddProxy(ctx context.Context, req AddProxyReq) (AddProxyResp, error) {
+	var resp AddProxyResp
+	err := c.do(ctx, "POST", "/proxy", nil, req, &resp)
+	return resp, err
+}
+
+func (c *Client) RemoveProxy(ctx context.Context, domain string) error {
+	return c.do(ctx, "DELETE", "/proxy", nil, map[string]string{"domain": domain}, nil)
+}
+
+func (c *Client) ProxyHealth(ctx context.Context, domain string) (HealthStatus, error) {
+	var status HealthStatus
+	err := c.do(ctx, "GET", "/proxy/"+url.PathEscape(domain)+"/health", nil, nil, &status)
+	return status, err
+}
+
+func (c *Client) EnableProxy(ctx context.Context, domain string) error {
+	return c.do(ctx, "POST", "/proxy/"+url.PathEscape(domain)+"/enable", nil, nil, nil)
+}
+
+func (c *Client) DisableProxy(ctx context.Context, domain string) error {
+	return c.do(ctx, "POST", "/proxy/"+url.PathEscape(domain)+"/disable", nil, nil, nil)
+}
+
+func (c *Client) SetMaintenance(ctx context.Context, domain string, enabled bool) error {
+	return c.do(ctx, "POST", "/proxy/"+url.PathEscape(domain)+"/maintenance", nil, map[string]bool{"enabled": enabled}, nil)
+}
+
+func (c *Client) CheckDomain(ctx context.Context, domain string) (DomainCheck, error) {
+	var check DomainCheck
+	err := c.do(ctx, "GET", "/domains/"+url.PathEscape(domain)+"/check", nil, nil, &check)
+	return check, err
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+func (c *Client) ListTokens(ctx context.Context) ([]Token, error) {
+	var tokens []Token
+	err := c.do(ctx, "GET", "/tokens", nil, nil, &tokens)
+	return tokens, err
+}
+
+func (c *Client) CreateToken(ctx context.Context, req CreateTokenReq) (Token, error) {
+	var token Token
+	err := c.do(ctx, "POST", "/tokens", nil, req, &token)
+	return token, err
+}
+
+func (c *Client) RevokeToken(ctx context.Context, name string) error {
+	return c.do(ctx, "DELETE", "/tokens/"+url.PathEscape(name), nil, nil, nil)
+}
+
+// Usage returns the caller's usage, or another token's when name is set
+// (admin only).
+func (c *Client) Usage(ctx context.Context, name string) (Usage, error) {
+	var query url.Values
+	if name != "" {
+		query = url.Values{"token": {name}}
+	}
+
+	var usage Usage
+	err := c.do(ctx, "GET", "/usage", query, nil, &usage)
+	return usage, err
+}
@@ -0,0 +1,33 @@
+package apierr
+
+import (
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+const stepKey = "npa_step"
+
+// SetStep records the provisioning step a handler is in; it is reported in
+// errors returned while the step runs.
+func SetStep(c *gin.Context, step string) {
+	c.Set(stepKey, step)
+}
+
+func Step(c *gin.Context) string {
+	return c.GetString(stepKey)
+}
+
+// Abort stops the request with an error envelope.
+func Abort(c *gin.Context, status int, code, message string) {
+	AbortWithDetails(c, status, code, message, nil)
+}
+
+func AbortWithDetails(c *gin.Context, status int, code, message string, details any) {
+	c.AbortWithStatusJSON(status, ErrorResp{Error: Error{
+		Code:      code,
+		Message:   message,
+		Step:      Step(c),
+		RequestID: requestid.Get(c),
+		Details:   details,
+	}})
+}
@@ -0,0 +1,48 @@
+package apierr
+
+// Machine-readable error codes returned in Error.Code.
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeInvalidDomain      = "invalid_domain"
+	CodeInvalidTarget      = "invalid_target"
+	CodeDomainNotAllowed   = "domain_not_allowed"
+	CodeProxyQuota         = "proxy_quota_exceeded"
+	CodeCertQuota          = "cert_quota_exceeded"
+	CodeTargetUnreachable  = "target_unreachable"
+	CodeCertMissing        = "cert_missing"
+	CodeCertbotFailed      = "certbot_failed"
+	CodeCloudflareFailed   = "cloudflare_failed"
+	CodeDNSRecordExists    = "dns_record_exists"
+	CodeDNSCheckFailed     = "dns_check_failed"
+	CodeNginxTestFailed    = "nginx_test_failed"
+	CodeNginxReloadFailed  = "nginx_reload_failed"
+	CodeNginxFailed        = "nginx_failed"
+	CodeProxyNotFound      = "proxy_not_found"
+	CodeProxyExists        = "proxy_exists"
+	CodeProxyPending       = "proxy_pending"
+	CodeStateFailed        = "state_failed"
+	CodeTokenExists        = "token_exists"
+	CodeTokenNotFound      = "token_not_found"
+	CodeTokenFailed        = "token_failed"
+	CodeAuditFailed        = "audit_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeIPDenied           = "ip_denied"
+	CodeLockedOut          = "locked_out"
+	CodeRateLimited        = "rate_limited"
+	CodeMissingScope       = "missing_scope"
+	CodeInternal           = "internal_error"
+)
+
+// ErrorResp is the body of every error response.
+type ErrorResp struct {
+	Error Error `json:"error"`
+}
+
+type Error struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Step      string `json:"step,omitempty"`
+	RequestID string `json:"request_id"`
+	Details   any    `json:"details,omitempty"`
+}
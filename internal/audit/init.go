@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// maxLine bounds a single audit entry when reading the log back.
+const maxLine = 1 << 20
+
+// Log is an append-only JSON lines audit trail. With chaining enabled every
+// entry carries the hash of the previous one, so edits or deletions in the
+// middle of the file are detected by Verify. The file is locked while it is
+// open: a second writer would fork the chain.
+type Log struct {
+	mu    sync.Mutex
+	path  string
+	chain bool
+	file  *os.File
+	last  string
+}
+
+func Open(path string, chain bool) (*Log, error) {
+	l := &Log{path: path, chain: chain}
+
+	var err error
+	l.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		l.file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("failed to lock audit log: %w", err)
+	}
+
+	err = l.scan(func(e Entry) error {
+		l.last = e.Hash
+		return nil
+	})
+	if err != nil {
+		l.file.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *Log) Write(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.chain {
+		e.PrevHash = l.last
+		hash, err := entryHash(e)
+		if err != nil {
+			return err
+		}
+		e.Hash = hash
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	l.last = e.Hash
+	return nil
+}
+
+// Query returns the newest entries matching f, oldest first.
+func (l *Log) Query(f Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []Entry
+	err := l.scan(func(e Entry) error {
+		if !f.From.IsZero() && e.Time.Before(f.From) {
+			return nil
+		}
+		if !f.To.IsZero() && e.Time.After(f.To) {
+			return nil
+		}
+		if f.Domain != "" && e.Domain != f.Domain {
+			return nil
+		}
+
+		entries = append(entries, e)
+		if f.Limit > 0 && len(entries) > f.Limit {
+			entries = entries[1:]
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Verify walks the hash chain. It returns the number of entries checked,
+// which on ErrChainBroken is the line of the first entry that does not match.
+// Once the chain has started, or whenever chaining is enabled, every entry
+// must be chained, and the last entry this process wrote must still be in
+// the file, so stripped hashes and a truncated tail are caught too.
+func (l *Log) Verify() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	line, prev := 0, ""
+	chained, found := false, l.last == ""
+	err := l.scan(func(e Entry) error {
+		line++
+		if e.Hash == "" {
+			if chained || l.chain {
+				return ErrChainBroken
+			}
+			// written before chaining was enabled
+			return nil
+		}
+		chained = true
+
+		want, err := entryHash(e)
+		if err != nil {
+			return err
+		}
+		if e.PrevHash != prev || e.Hash != want {
+			return ErrChainBroken
+		}
+		prev = e.Hash
+		if e.Hash == l.last {
+			found = true
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	if err == nil && !found {
+		return line + 1, ErrChainBroken
+	}
+	return line, err
+}
+
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+func (l *Log) scan(fn func(e Entry) error) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// entryHash hashes the entry with its Hash field cleared. Before and After
+// are re-encoded, so they hash the same after a round trip through the file.
+func entryHash(e Entry) (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return "", err
+	}
+	data, err = json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
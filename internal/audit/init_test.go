@@ -0,0 +1,196 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLog writes n chained entries and returns the open log and its lines.
+func writeLog(t *testing.T, n int) (*Log, []string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	for i := range n {
+		err := l.Write(Entry{
+			Time:    time.Unix(int64(i), 0).UTC(),
+			Actor:   "ci",
+			Op:      "proxy.add",
+			Domain:  "sub.example.com",
+			After:   map[string]any{"target": "node.example.com:8800"},
+			Status:  201,
+			Outcome: OutcomeSuccess,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func rewrite(t *testing.T, l *Log, lines []string) {
+	t.Helper()
+
+	data := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		data += "\n"
+	}
+	if err := os.WriteFile(l.path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		line   int
+	}{
+		{
+			name:   "intact",
+			tamper: func(lines []string) []string { return lines },
+		},
+		{
+			name: "edited entry",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"actor":"ci"`, `"actor":"admin"`, 1)
+				return lines
+			},
+			line: 2,
+		},
+		{
+			name: "deleted entry",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			line: 2,
+		},
+		{
+			name: "truncated head",
+			tamper: func(lines []string) []string {
+				return lines[1:]
+			},
+			line: 1,
+		},
+		{
+			name: "truncated tail",
+			tamper: func(lines []string) []string {
+				return lines[:2]
+			},
+			line: 3,
+		},
+		{
+			name: "emptied file",
+			tamper: func(lines []string) []string {
+				return nil
+			},
+			line: 1,
+		},
+		{
+			name: "stripped hashes",
+			tamper: func(lines []string) []string {
+				for i, line := range lines {
+					var e Entry
+					if err := json.Unmarshal([]byte(line), &e); err != nil {
+						panic(err)
+					}
+					e.PrevHash, e.Hash = "", ""
+					data, _ := json.Marshal(e)
+					lines[i] = string(data)
+				}
+				return lines
+			},
+			line: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, lines := writeLog(t, 4)
+			rewrite(t, l, tt.tamper(lines))
+
+			n, err := l.Verify()
+			if tt.line == 0 {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if n != len(lines) {
+					t.Fatalf("Verify() checked %d entries, want %d", n, len(lines))
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrChainBroken) {
+				t.Fatalf("Verify() error = %v, want %v", err, ErrChainBroken)
+			}
+			if n != tt.line {
+				t.Fatalf("Verify() line = %d, want %d", n, tt.line)
+			}
+		})
+	}
+}
+
+func TestVerifyUnchainedPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	plain, err := Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.Write(Entry{Actor: "ci", Op: "proxy.add", Outcome: OutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	plain.Close()
+
+	if n, err := plain.Verify(); err != nil || n != 1 {
+		t.Fatalf("Verify() = %d, %v with chaining disabled", n, err)
+	}
+
+	chained, err := Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chained.Close()
+	if err := chained.Write(Entry{Actor: "ci", Op: "proxy.remove", Outcome: OutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := chained.Verify(); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("Verify() error = %v, want %v for unchained entries with chaining enabled", err, ErrChainBroken)
+	}
+}
+
+func TestOpenLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l, err := Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, true); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second Open() error = %v, want %v", err, ErrLocked)
+	}
+
+	l.Close()
+	l, err = Open(path, true)
+	if err != nil {
+		t.Fatalf("Open() after Close() error = %v", err)
+	}
+	l.Close()
+}
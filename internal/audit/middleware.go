@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const detailsKey = "npa_audit"
+
+// Describe attaches what the current request changed to its audit entry.
+func Describe(c *gin.Context, d Details) {
+	c.Set(detailsKey, d)
+}
+
+// Record writes an audit entry for op after the handler has run.
+func Record(l *Log, log *zap.Logger, op string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		v, _ := c.Get(detailsKey)
+		d, _ := v.(Details)
+		if d.Domain == "" {
+			d.Domain = c.Param("domain")
+		}
+
+		status := c.Writer.Status()
+		outcome := OutcomeSuccess
+		if status >= http.StatusBadRequest {
+			outcome = OutcomeFailure
+		}
+
+		err := l.Write(Entry{
+			Time:    time.Now().UTC(),
+			Actor:   auth.IdentityFrom(c).Name,
+			IP:      c.ClientIP(),
+			Op:      op,
+			Domain:  d.Domain,
+			Target:  d.Target,
+			Before:  d.Before,
+			After:   d.After,
+			Status:  status,
+			Outcome: outcome,
+		})
+		if err != nil {
+			log.Error("failed to write audit log", zap.String("op", op), zap.Error(err))
+		}
+	}
+}
+
+// Reject writes an audit entry for a request that was turned away before it
+// reached a handler, such as a failed authentication.
+func Reject(l *Log, log *zap.Logger, c *gin.Context, op string, status int, reason string) {
+	err := l.Write(Entry{
+		Time:    time.Now().UTC(),
+		IP:      c.ClientIP(),
+		Op:      op,
+		Status:  status,
+		Outcome: OutcomeFailure,
+		Reason:  reason,
+	})
+	if err != nil {
+		log.Error("failed to write audit log", zap.String("op", op), zap.Error(err))
+	}
+}
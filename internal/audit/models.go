@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+const (
+	OpAuthFailure = "auth.failure"
+	OpAuthBan     = "auth.ban"
+)
+
+var (
+	ErrChainBroken = errors.New("audit_chain_broken")
+	ErrLocked      = errors.New("audit log is in use by another npapi process")
+)
+
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Actor   string    `json:"actor"`
+	IP      string    `json:"ip"`
+	Op      string    `json:"op"`
+	Domain  string    `json:"domain,omitempty"`
+	Target  string    `json:"target,omitempty"`
+	Before  any       `json:"before,omitempty"`
+	After   any       `json:"after,omitempty"`
+	Status  int       `json:"status"`
+	Outcome string    `json:"outcome"`
+	Reason  string    `json:"reason,omitempty"`
+
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// Details are filled in by handlers to describe what a request changed.
+type Details struct {
+	Domain string
+	Target string
+	Before any
+	After  any
+}
+
+type Filter struct {
+	From   time.Time
+	To     time.Time
+	Domain string
+	Limit  int
+}
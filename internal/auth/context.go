@@ -0,0 +1,16 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+const identityKey = "npa_identity"
+
+func SetIdentity(c *gin.Context, id Identity) {
+	c.Set(identityKey, id)
+}
+
+// IdentityFrom returns the caller set by the auth middleware.
+func IdentityFrom(c *gin.Context) Identity {
+	id, _ := c.Get(identityKey)
+	identity, _ := id.(Identity)
+	return identity
+}
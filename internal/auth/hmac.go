@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/signature"
+)
+
+// maxSignedBody caps how much of the request body is read for hashing.
+const maxSignedBody = 1 << 20
+
+// Verifier checks signed requests against HMAC tokens and remembers nonces
+// for the allowed clock skew so a captured request cannot be replayed.
+type Verifier struct {
+	tokens  *Tokens
+	maxSkew time.Duration
+
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	pruned time.Time
+}
+
+func NewVerifier(tokens *Tokens, maxSkew time.Duration) *Verifier {
+	return &Verifier{
+		tokens:  tokens,
+		maxSkew: maxSkew,
+		nonces:  make(map[string]time.Time),
+	}
+}
+
+func (v *Verifier) Verify(r *http.Request) (Identity, error) {
+	name, sig, err := parseSignedAuth(r.Header.Get("Authorization"))
+	if err != nil {
+		return Identity{}, err
+	}
+
+	timestamp := r.Header.Get(signature.HeaderTimestamp)
+	nonce := r.Header.Get(signature.HeaderNonce)
+	if nonce == "" {
+		return Identity{}, fmt.Errorf("%w: missing nonce", ErrBadSignature)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: invalid timestamp", ErrBadSignature)
+	}
+	signedAt := time.Unix(unix, 0)
+	if skew := time.Since(signedAt).Abs(); skew > v.maxSkew {
+		return Identity{}, ErrClockSkew
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(body) > maxSignedBody {
+		return Identity{}, fmt.Errorf("%w: body too large", ErrBadSignature)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	secret, err := v.tokens.secret(name)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	expected := signature.Sign(secret, signature.StringToSign(r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(sig))) {
+		return Identity{}, ErrBadSignature
+	}
+
+	if !v.remember(name+"\n"+nonce, signedAt) {
+		return Identity{}, ErrReplay
+	}
+
+	return v.tokens.use(name)
+}
+
+// remember stores the nonce and reports false if it was already seen.
+func (v *Verifier) remember(key string, signedAt time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(v.pruned) >= time.Minute {
+		v.pruned = now
+		for k, exp := range v.nonces {
+			if now.After(exp) {
+				delete(v.nonces, k)
+			}
+		}
+	}
+
+	if _, seen := v.nonces[key]; seen {
+		return false
+	}
+	v.nonces[key] = signedAt.Add(v.maxSkew)
+	return true
+}
+
+func parseSignedAuth(header string) (name, sig string, err error) {
+	params, ok := strings.CutPrefix(header, signature.Scheme+" ")
+	if !ok {
+		return "", "", ErrBadSignature
+	}
+
+	for _, part := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "Credential":
+			name = value
+		case "Signature":
+			sig = value
+		}
+	}
+	if name == "" || sig == "" {
+		return "", "", fmt.Errorf("%w: missing credential or signature", ErrBadSignature)
+	}
+	return name, sig, nil
+}
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// lastUsedFlush limits how often last-used timestamps are written to disk.
+const lastUsedFlush = time.Minute
+
+// tokensFileMode keeps HMAC secrets in the tokens file private.
+const tokensFileMode = 0o600
+
+// masterName is the identity of the token from the `access.token` config key.
+const masterName = "config"
+
+type Tokens struct {
+	mu      sync.Mutex
+	path    string
+	master  string
+	tokens  map[string]Token
+	byHash  map[string]string
+	flushed time.Time
+}
+
+func LoadTokens(path, master string) (*Tokens, error) {
+	t := &Tokens{path: path, master: master}
+
+	tokens, byHash, err := readTokens(path)
+	if err != nil {
+		return nil, err
+	}
+	t.tokens, t.byHash = tokens, byHash
+
+	return t, nil
+}
+
+// PrepareReload re-reads the tokens file. Calling apply replaces the tokens
+// and the master token; on error nothing changes, so a config reload can
+// read everything first and then swap it in at once.
+func (t *Tokens) PrepareReload(master string) (apply func(), err error) {
+	tokens, byHash, err := readTokens(t.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		t.mu.Lock()
+		t.master = master
+		t.tokens, t.byHash = tokens, byHash
+		t.mu.Unlock()
+	}, nil
+}
+
+func readTokens(path string) (map[string]Token, map[string]string, error) {
+	tokens := make(map[string]Token)
+	byHash := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tokens, byHash, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+	// HMAC secrets are stored as is, so the file must not be readable by
+	// anyone else
+	if err := restrictMode(path); err != nil {
+		return nil, nil, err
+	}
+
+	var list []Token
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse tokens file: %w", err)
+	}
+	for _, tok := range list {
+		tokens[tok.Name] = tok
+		if tok.Hash != "" {
+			byHash[tok.Hash] = tok.Name
+		}
+	}
+
+	return tokens, byHash, nil
+}
+
+// Authenticate resolves a raw bearer token to the identity it belongs to.
+func (t *Tokens) Authenticate(raw string) (Identity, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.master != "" && subtle.ConstantTimeCompare([]byte(raw), []byte(t.master)) == 1 {
+		return Identity{Name: masterName, Scopes: []string{ScopeAdmin}}, nil
+	}
+
+	name, ok := t.byHash[hashToken(raw)]
+	if !ok {
+		return Identity{}, ErrInvalidToken
+	}
+
+	return t.touch(name)
+}
+
+// touch checks that the token is still valid and records its last use.
+// Callers must hold t.mu.
+func (t *Tokens) touch(name string) (Identity, error) {
+	tok := t.tokens[name]
+	now := time.Now()
+	if tok.ExpiresAt != nil && now.After(*tok.ExpiresAt) {
+		return Identity{}, ErrTokenExpired
+	}
+
+	tok.LastUsed = &now
+	t.tokens[name] = tok
+	if now.Sub(t.flushed) >= lastUsedFlush {
+		if err := t.save(); err == nil {
+			t.flushed = now
+		}
+	}
+
+	return Identity{Name: tok.Name, Scopes: tok.Scopes, Domains: tok.Domains, Limits: tok.Limits}, nil
+}
+
+// secret returns the shared secret of an HMAC token.
+func (t *Tokens) secret(name string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tok, ok := t.tokens[name]
+	if !ok || tok.Scheme != SchemeHMAC {
+		return "", ErrInvalidToken
+	}
+	return tok.Secret, nil
+}
+
+func (t *Tokens) use(name string) (Identity, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.tokens[name]; !ok {
+		return Identity{}, ErrInvalidToken
+	}
+	return t.touch(name)
+}
+
+// Create issues a new token and returns its secret. The secret is not stored
+// and cannot be recovered later.
+func (t *Tokens) Create(name, scheme string, scopes, domains []string, expiresAt *time.Time, limits *Limits) (string, Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.tokens[name]; ok || name == masterName {
+		return "", Token{}, ErrTokenExists
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", Token{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	raw := "npa_" + hex.EncodeToString(buf)
+
+	tok := Token{
+		Name:      name,
+		Scheme:    scheme,
+		Scopes:    scopes,
+		Domains:   domains,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+		Limits:    limits,
+	}
+	if scheme == SchemeHMAC {
+		tok.Secret = raw
+	} else {
+		tok.Hash = hashToken(raw)
+		t.byHash[tok.Hash] = name
+	}
+	t.tokens[name] = tok
+
+	if err := t.save(); err != nil {
+		delete(t.tokens, name)
+		delete(t.byHash, tok.Hash)
+		return "", Token{}, err
+	}
+
+	return raw, tok, nil
+}
+
+// Lookup returns the identity of a named token without using it.
+func (t *Tokens) Lookup(name string) (Identity, bool) {
+	if name == masterName {
+		return Identity{Name: masterName, Scopes: []string{ScopeAdmin}}, true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tok, ok := t.tokens[name]
+	if !ok {
+		return Identity{}, false
+	}
+	return Identity{Name: tok.Name, Scopes: tok.Scopes, Domains: tok.Domains, Limits: tok.Limits}, true
+}
+
+func (t *Tokens) List() []Token {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.list()
+}
+
+func (t *Tokens) Revoke(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tok, ok := t.tokens[name]
+	if !ok {
+		return ErrTokenNotFound
+	}
+	delete(t.tokens, name)
+	delete(t.byHash, tok.Hash)
+
+	return t.save()
+}
+
+func (t *Tokens) list() []Token {
+	tokens := make([]Token, 0, len(t.tokens))
+	for _, tok := range t.tokens {
+		tokens = append(tokens, tok)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens
+}
+
+func (t *Tokens) save() error {
+	data, err := json.MarshalIndent(t.list(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), filepath.Base(t.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(tokensFileMode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write tokens: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write tokens: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write tokens: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), t.path); err != nil {
+		return fmt.Errorf("failed to replace tokens file: %w", err)
+	}
+	return nil
+}
+
+// restrictMode makes the tokens file readable by its owner only.
+func restrictMode(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tokens file: %w", err)
+	}
+	if info.Mode().Perm()&^tokensFileMode == 0 {
+		return nil
+	}
+	if err := os.Chmod(path, tokensFileMode); err != nil {
+		return fmt.Errorf("tokens file %s is readable by other users: %w", path, err)
+	}
+	return nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
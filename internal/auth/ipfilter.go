@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// IPFilter decides which client addresses may reach the API. Entries are
+// single IPs or CIDR ranges; deny entries win over allow entries. The lists
+// can be swapped at runtime with Update.
+type IPFilter struct {
+	mu    sync.RWMutex
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+func NewIPFilter(allow, deny []string) (*IPFilter, error) {
+	f := &IPFilter{}
+	if err := f.Update(allow, deny); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *IPFilter) Update(allow, deny []string) error {
+	allowPrefixes, err := ParsePrefixes(allow)
+	if err != nil {
+		return fmt.Errorf("invalid allowed_ips: %w", err)
+	}
+	denyPrefixes, err := ParsePrefixes(deny)
+	if err != nil {
+		return fmt.Errorf("invalid denied_ips: %w", err)
+	}
+
+	f.Set(allowPrefixes, denyPrefixes)
+	return nil
+}
+
+// Set replaces the lists with already parsed prefixes.
+func (f *IPFilter) Set(allow, deny []netip.Prefix) {
+	f.mu.Lock()
+	f.allow = allow
+	f.deny = deny
+	f.mu.Unlock()
+}
+
+func (f *IPFilter) Allowed(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, p := range f.deny {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	for _, p := range f.allow {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrefixes parses IPs and CIDR ranges. IPv4-mapped IPv6 addresses are
+// normalized to plain IPv4 and a bare IP becomes a single-address range.
+func ParsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+
+		if strings.Contains(entry, "/") {
+			p, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			if p.Addr().Is4In6() && p.Bits() >= 96 {
+				p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
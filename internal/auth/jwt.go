@@ -0,0 +1,391 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"go.uber.org/zap"
+)
+
+// jwksRetry limits how often an unknown key ID triggers a JWKS refetch.
+const jwksRetry = time.Minute
+
+// jwtIdentityPrefix keeps JWT identities apart from API token names, so a
+// subject cannot act as the token with the same name.
+const jwtIdentityPrefix = "oidc:"
+
+var (
+	ErrInvalidJWT = errors.New("invalid_jwt")
+	ErrUnknownKey = errors.New("unknown_jwt_key")
+)
+
+// esCurves is the curve each ECDSA algorithm is defined for.
+var esCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+// JWTVerifier validates JWTs issued by an OIDC provider against its JWKS
+// and maps their claims to an API identity.
+type JWTVerifier struct {
+	cfg    config.JWT
+	log    *zap.Logger
+	client *http.Client
+
+	mu      sync.RWMutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+func NewJWTVerifier(cfg config.JWT, log *zap.Logger) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		cfg:    cfg,
+		log:    log,
+		client: &http.Client{Timeout: 10 * time.Second},
+		keys:   make(map[string]crypto.PublicKey),
+	}
+
+	if err := v.refresh(); err != nil {
+		if cfg.JWKSFile != "" {
+			return nil, err
+		}
+		// the identity provider may be temporarily down, keys are fetched
+		// again on the first token
+		log.Error("failed to fetch JWKS", zap.String("url", cfg.JWKSURL), zap.Error(err))
+	}
+	return v, nil
+}
+
+// LooksLikeJWT tells JWTs apart from opaque API tokens.
+func LooksLikeJWT(token string) bool {
+	return strings.HasPrefix(token, "eyJ") && strings.Count(token, ".") == 2
+}
+
+func (v *JWTVerifier) Verify(token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, ErrInvalidJWT
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, ErrInvalidJWT
+	}
+
+	key, err := v.key(header.Kid)
+	if err != nil {
+		return Identity{}, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return Identity{}, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return Identity{}, err
+	}
+
+	return v.identity(claims)
+}
+
+func (v *JWTVerifier) validateClaims(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidJWT)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.cfg.Leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidJWT)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidJWT)
+	}
+
+	if v.cfg.Issuer != "" && claims["iss"] != v.cfg.Issuer {
+		return fmt.Errorf("%w: wrong issuer", ErrInvalidJWT)
+	}
+	if v.cfg.Audience != "" && !slices.Contains(claimStrings(claims["aud"]), v.cfg.Audience) {
+		return fmt.Errorf("%w: wrong audience", ErrInvalidJWT)
+	}
+
+	return nil
+}
+
+// identity maps the scope claim through ScopeMap. Without a map, claim
+// values that are known scopes are used directly.
+func (v *JWTVerifier) identity(claims map[string]any) (Identity, error) {
+	name, _ := claims[v.cfg.NameClaim].(string)
+	if name == "" {
+		return Identity{}, fmt.Errorf("%w: missing %s claim", ErrInvalidJWT, v.cfg.NameClaim)
+	}
+
+	var scopes []string
+	for _, value := range claimStrings(claims[v.cfg.ScopeClaim]) {
+		mapped := []string{value}
+		if len(v.cfg.ScopeMap) > 0 {
+			mapped = v.cfg.ScopeMap[value]
+		}
+		for _, scope := range mapped {
+			if slices.Contains(Scopes, scope) && !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return Identity{Name: jwtIdentityPrefix + name, Scopes: scopes, Domains: v.cfg.Domains}, nil
+}
+
+func (v *JWTVerifier) key(kid string) (crypto.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	fetched := v.fetched
+	v.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+	if v.cfg.JWKSURL == "" || time.Since(fetched) < jwksRetry {
+		return nil, ErrUnknownKey
+	}
+
+	if err := v.refresh(); err != nil {
+		v.log.Error("failed to fetch JWKS", zap.String("url", v.cfg.JWKSURL), zap.Error(err))
+		return nil, ErrUnknownKey
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (v *JWTVerifier) refresh() error {
+	v.mu.Lock()
+	v.fetched = time.Now()
+	v.mu.Unlock()
+
+	var data []byte
+	var err error
+	if v.cfg.JWKSFile != "" {
+		data, err = os.ReadFile(v.cfg.JWKSFile)
+	} else {
+		data, err = v.fetch()
+	}
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWTVerifier) fetch() ([]byte, error) {
+	resp, err := v.client.Get(v.cfg.JWKSURL)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, err1 := decodeBigInt(k.N)
+			e, err2 := decodeBigInt(k.E)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid RSA key %q", k.Kid)
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err1 := decodeBigInt(k.X)
+			y, err2 := decodeBigInt(k.Y)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid EC key %q", k.Kid)
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+
+	return keys, nil
+}
+
+// verifySignature checks sig with key for alg. The key type has to match the
+// algorithm family, and for ES* also the curve, so a token cannot pick a
+// weaker verification than the key was published for.
+func verifySignature(alg string, key crypto.PublicKey, signed string, sig []byte) error {
+	var h crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		h = crypto.SHA256
+	case "384":
+		h = crypto.SHA384
+	case "512":
+		h = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidJWT, alg)
+	}
+	digest := hashOf(h, signed)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: alg %q does not match the key", ErrInvalidJWT, alg)
+		}
+		var err error
+		if alg[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(pub, h, digest, sig)
+		} else {
+			err = rsa.VerifyPSS(pub, h, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidJWT)
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != esCurves[alg] {
+			return fmt.Errorf("%w: alg %q does not match the key", ErrInvalidJWT, alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return fmt.Errorf("%w: bad signature", ErrInvalidJWT)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidJWT)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidJWT, alg)
+	}
+	return nil
+}
+
+func hashOf(h crypto.Hash, data string) []byte {
+	var hasher hash.Hash
+	switch h {
+	case crypto.SHA384:
+		hasher = sha512.New384()
+	case crypto.SHA512:
+		hasher = sha512.New()
+	default:
+		hasher = sha256.New()
+	}
+	hasher.Write([]byte(data))
+	return hasher.Sum(nil)
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrInvalidJWT
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidJWT
+	}
+	return nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// claimStrings accepts both space-separated string claims (like "scope")
+// and array claims (like "groups" or "aud").
+func claimStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Lockout counts failed authentication attempts per client IP and bans an IP
+// for a while once it exceeds maxFailures within window.
+type Lockout struct {
+	mu          sync.Mutex
+	maxFailures int
+	window      time.Duration
+	ban         time.Duration
+	clients     map[string]*attempts
+	pruned      time.Time
+}
+
+type attempts struct {
+	failures    int
+	first       time.Time
+	bannedUntil time.Time
+}
+
+func NewLockout(maxFailures int, window, ban time.Duration) *Lockout {
+	return &Lockout{
+		maxFailures: maxFailures,
+		window:      window,
+		ban:         ban,
+		clients:     make(map[string]*attempts),
+	}
+}
+
+// Banned returns how long ip stays banned, or 0 if it is not banned.
+func (l *Lockout) Banned(ip string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.clients[ip]
+	if !ok {
+		return 0
+	}
+	if left := time.Until(a.bannedUntil); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Fail records a failed attempt and reports whether ip is now banned.
+func (l *Lockout) Fail(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	a, ok := l.clients[ip]
+	if !ok || now.Sub(a.first) > l.window {
+		a = &attempts{first: now}
+		l.clients[ip] = a
+	}
+
+	a.failures++
+	if a.failures >= l.maxFailures {
+		a.bannedUntil = now.Add(l.ban)
+		a.failures = 0
+		a.first = now
+		return true
+	}
+	return false
+}
+
+// Reset forgets failed attempts of ip after a successful login.
+func (l *Lockout) Reset(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if a, ok := l.clients[ip]; ok && time.Now().After(a.bannedUntil) {
+		delete(l.clients, ip)
+	}
+}
+
+func (l *Lockout) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+
+	for ip, a := range l.clients {
+		if now.Sub(a.first) > l.window && now.After(a.bannedUntil) {
+			delete(l.clients, ip)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeProxyRead  = "proxy:read"
+	ScopeProxyWrite = "proxy:write"
+	ScopeCertManage = "cert:manage"
+	ScopeAdmin      = "admin"
+)
+
+var Scopes = []string{ScopeProxyRead, ScopeProxyWrite, ScopeCertManage, ScopeAdmin}
+
+// Token schemes. Bearer tokens are sent as is, HMAC tokens sign every request
+// and are never accepted as bearer tokens.
+const (
+	SchemeBearer = "bearer"
+	SchemeHMAC   = "hmac"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid_token")
+	ErrTokenExpired  = errors.New("token_expired")
+	ErrTokenExists   = errors.New("token_exists")
+	ErrTokenNotFound = errors.New("token_not_found")
+	ErrBadSignature  = errors.New("bad_signature")
+	ErrClockSkew     = errors.New("clock_skew")
+	ErrReplay        = errors.New("replayed_request")
+)
+
+// Token is a named API token. For bearer tokens only the SHA-256 hash of the
+// secret is kept; HMAC tokens need the shared secret itself to verify
+// signatures, so it is stored in plain text and the tokens file is kept at
+// mode 0600.
+type Token struct {
+	Name      string     `json:"name"`
+	Scheme    string     `json:"scheme,omitempty"`
+	Hash      string     `json:"hash,omitempty"`
+	Secret    string     `json:"secret,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	Domains   []string   `json:"domains,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+	Limits    *Limits    `json:"limits,omitempty"`
+}
+
+// Limits override the configured rate limit and quotas for one token.
+// Zero fields fall back to the defaults.
+type Limits struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	MaxProxies        int `json:"max_proxies,omitempty"`
+	MaxCertsPerWeek   int `json:"max_certs_per_week,omitempty"`
+}
+
+// Identity describes the authenticated caller of a request.
+type Identity struct {
+	Name    string
+	Scopes  []string
+	Domains []string
+	Limits  *Limits
+}
+
+func (id Identity) HasScope(scope string) bool {
+	return slices.Contains(id.Scopes, ScopeAdmin) || slices.Contains(id.Scopes, scope)
+}
+
+// AllowsDomain reports whether domain is equal to or below one of the
+// identity's allowed domain suffixes. No suffixes means no restriction.
+func (id Identity) AllowsDomain(domain string) bool {
+	if len(id.Domains) == 0 {
+		return true
+	}
+	for _, suffix := range id.Domains {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
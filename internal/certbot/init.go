@@ -1,13 +1,22 @@
 package certbot
 
 import (
-	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/metrics"
+	"github.com/d1manpro/nginx-proxy-api/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
 )
 
-func GetCert(domain, email string) error {
+func GetCert(ctx context.Context, domain, email string) error {
 	cmd := exec.Command("certbot", "certonly",
 		"--nginx",
 		"-d", domain,
@@ -15,19 +24,19 @@ func GetCert(domain, email string) error {
 		"--non-interactive",
 		"-m", email,
 	)
-	output, err := cmd.CombinedOutput()
+	output, err := run(ctx, "certonly", cmd)
 	if err != nil {
 		return fmt.Errorf("certbot get error: %w, output: %s", err, output)
 	}
 	return nil
 }
 
-func DeleteCert(domain string) error {
+func DeleteCert(ctx context.Context, domain string) error {
 	cmd := exec.Command("certbot", "delete",
 		"--cert-name", domain,
 		"--non-interactive",
 	)
-	output, err := cmd.CombinedOutput()
+	output, err := run(ctx, "delete", cmd)
 	if err != nil {
 		return fmt.Errorf("certbot delete error: %w, output: %s", err, output)
 	}
@@ -35,15 +44,94 @@ func DeleteCert(domain string) error {
 	return nil
 }
 
-func IsCertExists(domain string) (bool, error) {
-	cmd := exec.Command( "certbot", "certificates")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
+func IsCertExists(ctx context.Context, domain string) (bool, error) {
+	output, err := run(ctx, "certificates", exec.Command("certbot", "certificates"))
+	if err != nil {
+		return false, fmt.Errorf("certbot get-cert-list error: %v\n%s", err, output)
+	}
 
-	if err := cmd.Run(); err != nil {
-		return false, fmt.Errorf("certbot get-cert-list error: %v\n%s", err, out.String())
+	return strings.Contains(string(output), domain), nil
+}
+
+// RenewCert renews one certificate. Without force certbot only renews it
+// when it is close to expiry.
+func RenewCert(ctx context.Context, name string, force bool) error {
+	args := []string{"renew", "--cert-name", name, "--non-interactive"}
+	if force {
+		args = append(args, "--force-renewal")
 	}
 
-	return strings.Contains(out.String(), domain), nil
+	output, err := run(ctx, "renew", exec.Command("certbot", args...))
+	if err != nil {
+		return fmt.Errorf("certbot renew error: %w, output: %s", err, output)
+	}
+	return nil
+}
+
+// ListCerts reads the certificates in certbot's live directory.
+func ListCerts() ([]Cert, error) {
+	entries, err := os.ReadDir(liveDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []Cert
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		cert, err := ReadCert(e.Name())
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// ReadCert parses the certificate name from certbot's live directory.
+func ReadCert(name string) (Cert, error) {
+	data, err := os.ReadFile(filepath.Join(liveDir, name, "cert.pem"))
+	if err != nil {
+		return Cert{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Cert{}, fmt.Errorf("no PEM data in %s/cert.pem", name)
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return Cert{}, err
+	}
+
+	return Cert{
+		Name:     name,
+		Domains:  parsed.DNSNames,
+		NotAfter: parsed.NotAfter,
+		DaysLeft: int(time.Until(parsed.NotAfter).Hours() / 24),
+	}, nil
+}
+
+// CheckInstalled makes sure the certbot binary is available.
+func CheckInstalled() error {
+	_, err := exec.LookPath("certbot")
+	return err
+}
+
+// run executes cmd under a span that is a child of ctx. ctx is not used to
+// cancel the command, certbot is left to finish what it started.
+func run(ctx context.Context, command string, cmd *exec.Cmd) ([]byte, error) {
+	// the command line is left out, it contains the account email
+	_, span := tracing.Start(ctx, "certbot "+command)
+	defer span.End()
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	metrics.CertbotDuration.WithLabelValues(command, metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return output, err
 }
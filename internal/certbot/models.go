@@ -0,0 +1,13 @@
+package certbot
+
+import "time"
+
+const liveDir = "/etc/letsencrypt/live"
+
+// Cert is a certificate managed by certbot, read from its live directory.
+type Cert struct {
+	Name     string    `json:"name"`
+	Domains  []string  `json:"domains"`
+	NotAfter time.Time `json:"not_after"`
+	DaysLeft int       `json:"days_left"`
+}
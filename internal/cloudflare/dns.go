@@ -2,16 +2,19 @@ package cloudflare
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 )
 
-func (c *CfAPI) GetAllSubdomains(domain, zoneID string) ([]string, error) {
-	req, err := http.NewRequest("GET", "https://api.cloudflare.com/client/v4/zones/"+zoneID+"/dns_records?per_page=1000", nil)
+func (c *CfAPI) GetAllSubdomains(ctx context.Context, domain, zoneID string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.cloudflare.com/client/v4/zones/"+zoneID+"/dns_records?per_page=1000", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -57,61 +60,69 @@ func (c *CfAPI) GetAllSubdomains(domain, zoneID string) ([]string, error) {
 	return subdomains, nil
 }
 
-func (c *CfAPI) CreateDNSRecord(zoneID string, data any) error {
-	bodyBytes, err := json.Marshal(data)
+func (c *CfAPI) CreateDNSRecord(ctx context.Context, zoneID string, record NewDNSRecord) (DNSRecord, error) {
+	if record.Comment == "" {
+		record.Comment = ManagedComment
+	}
+
+	bodyBytes, err := json.Marshal(record)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %w", err)
+		return DNSRecord{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.cloudflare.com/client/v4/zones/"+zoneID+"/dns_records", bytes.NewReader(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.cloudflare.com/client/v4/zones/"+zoneID+"/dns_records", bytes.NewReader(bodyBytes))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return DNSRecord{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return DNSRecord{}, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return DNSRecord{}, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	var cfResp CloudflareResponse
 	if err := json.Unmarshal(respBody, &cfResp); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
+		return DNSRecord{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if cfResp.Success {
-		return nil
+		return cfResp.Result, nil
 	}
 
 	for _, errObj := range cfResp.Errors {
 		if errObj.Code == 81058 {
-			return ErrRecordExists
+			return DNSRecord{}, ErrRecordExists
 		}
 	}
 
-	return fmt.Errorf("failed to create DNS record: %v", cfResp.Errors)
+	return DNSRecord{}, fmt.Errorf("failed to create DNS record: %v", cfResp.Errors)
 }
 
-func (c *CfAPI) DeleteDNSRecord(zoneID, name string) error {
-	req, err := http.NewRequest("GET", "https://api.cloudflare.com/client/v4/zones/"+zoneID+"/dns_records?name="+name, nil)
+func (c *CfAPI) ListDNSRecords(ctx context.Context, zoneID, name string) ([]DNSRecord, error) {
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("per_page", "100")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.cloudflare.com/client/v4/zones/"+zoneID+"/dns_records?"+query.Encode(), nil)
 	if err != nil {
-		return fmt.Errorf("failed to create GET request: %w", err)
+		return nil, fmt.Errorf("failed to create GET request: %w", err)
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("GET request failed: %w", err)
+		return nil, fmt.Errorf("GET request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read GET response body: %w", err)
+		return nil, fmt.Errorf("failed to read GET response body: %w", err)
 	}
 
 	var listResp struct {
@@ -121,18 +132,108 @@ func (c *CfAPI) DeleteDNSRecord(zoneID, name string) error {
 	}
 
 	if err := json.Unmarshal(respBody, &listResp); err != nil {
-		return fmt.Errorf("failed to unmarshal GET response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal GET response: %w", err)
 	}
 
-	if !listResp.Success || len(listResp.Result) == 0 {
-		return fmt.Errorf("record not found or API error: %v", listResp.Errors)
+	if !listResp.Success {
+		return nil, fmt.Errorf("failed to list DNS records: %v", listResp.Errors)
 	}
 
-	recordID := listResp.Result[0].ID
+	return listResp.Result, nil
+}
+
+func (c *CfAPI) GetDNSRecord(ctx context.Context, zoneID, recordID string) (DNSRecord, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.cloudflare.com/client/v4/zones/"+zoneID+"/dns_records/"+recordID, nil)
+	if err != nil {
+		return DNSRecord{}, fmt.Errorf("failed to create GET request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return DNSRecord{}, fmt.Errorf("GET request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return DNSRecord{}, ErrRecordNotFound
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return DNSRecord{}, fmt.Errorf("failed to read GET response body: %w", err)
+	}
+
+	var cfResp CloudflareResponse
+	if err := json.Unmarshal(respBody, &cfResp); err != nil {
+		return DNSRecord{}, fmt.Errorf("failed to unmarshal GET response: %w", err)
+	}
+
+	if !cfResp.Success {
+		return DNSRecord{}, fmt.Errorf("failed to get DNS record: %v", cfResp.Errors)
+	}
+
+	return cfResp.Result, nil
+}
+
+// DeleteDNSRecords removes the records this service created for name. When
+// recordIDs are known (saved at creation time) only those records are touched,
+// otherwise all managed A/AAAA/CNAME records for the name are deleted.
+// Records that were not created by npapi are never deleted: they are skipped,
+// the managed ones are still removed, and ErrRecordNotManaged is returned.
+func (c *CfAPI) DeleteDNSRecords(ctx context.Context, zoneID, name string, recordIDs []string) error {
+	var records []DNSRecord
+	var unmanaged []string
+
+	if len(recordIDs) > 0 {
+		for _, id := range recordIDs {
+			record, err := c.GetDNSRecord(ctx, zoneID, id)
+			if errors.Is(err, ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if record.Name != name || !record.IsManaged() {
+				unmanaged = append(unmanaged, record.Type+" "+record.Name)
+				continue
+			}
+			records = append(records, record)
+		}
+	} else {
+		all, err := c.ListDNSRecords(ctx, zoneID, name)
+		if err != nil {
+			return err
+		}
+		if len(all) == 0 {
+			return ErrRecordNotFound
+		}
+
+		for _, record := range all {
+			if record.IsManaged() {
+				records = append(records, record)
+			}
+		}
+		if len(records) == 0 {
+			return ErrRecordNotManaged
+		}
+	}
+
+	for _, record := range records {
+		if err := c.deleteDNSRecord(ctx, zoneID, record.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(unmanaged) > 0 {
+		return fmt.Errorf("%w: %s", ErrRecordNotManaged, strings.Join(unmanaged, ", "))
+	}
+	return nil
+}
 
+func (c *CfAPI) deleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
 	delURL := "https://api.cloudflare.com/client/v4/zones/" + zoneID + "/dns_records/" + recordID
 
-	delReq, err := http.NewRequest("DELETE", delURL, nil)
+	delReq, err := http.NewRequestWithContext(ctx, "DELETE", delURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create DELETE request: %w", err)
 	}
@@ -3,19 +3,25 @@ package cloudflare
 import (
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/metrics"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/d1manpro/nginx-proxy-api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
 type CfAPI struct {
 	client *http.Client
-	cfg    *config.Config
+	cfg    *config.Current
 	log    *zap.Logger
 }
 
-func InitCfAPI(cfg *config.Config, log *zap.Logger) *CfAPI {
+func InitCfAPI(cfg *config.Current, log *zap.Logger) *CfAPI {
 	return &CfAPI{
 		client: &http.Client{
 			Timeout: 15 * time.Second,
@@ -40,20 +46,30 @@ func InitCfAPI(cfg *config.Config, log *zap.Logger) *CfAPI {
 
 type RoundTripper struct {
 	logger *zap.Logger
-	cfg    *config.Config
+	cfg    *config.Current
 	rt     http.RoundTripper
 }
 
 func (l *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Add("Authorization", "Bearer "+l.cfg.Cloudflare.Token)
+	req.Header.Add("Authorization", "Bearer "+l.cfg.Get().Cloudflare.Token)
 	req.Header.Add("Content-Type", "application/json")
 
+	ctx, span := tracing.Start(req.Context(), "cloudflare "+req.Method,
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.path", req.URL.Path),
+	)
+	defer span.End()
+
 	start := time.Now()
-	resp, err := l.rt.RoundTrip(req)
+	resp, err := l.rt.RoundTrip(req.WithContext(ctx))
 	duration := time.Since(start)
 
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.CloudflareDuration.WithLabelValues(req.Method, "error").Observe(duration.Seconds())
 		l.logger.Error("HTTP request failed",
+			zap.String("request_id", requestid.FromContext(ctx)),
 			zap.String("method", req.Method),
 			zap.String("url", req.URL.String()),
 			zap.Duration("duration", duration),
@@ -62,7 +78,13 @@ func (l *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
+	metrics.CloudflareDuration.WithLabelValues(req.Method, strconv.Itoa(resp.StatusCode)).Observe(duration.Seconds())
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
 	l.logger.Info("HTTP request",
+		zap.String("request_id", requestid.FromContext(ctx)),
 		zap.String("method", req.Method),
 		zap.String("url", req.URL.String()),
 		zap.Int("status", resp.StatusCode),
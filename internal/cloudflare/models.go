@@ -5,7 +5,24 @@ import (
 	"time"
 )
 
-var ErrRecordExists = errors.New("dns_record_exists")
+// ManagedComment marks DNS records created by this service. Only records
+// carrying it are ever deleted.
+const ManagedComment = "managed by nginx-proxy-api"
+
+var (
+	ErrRecordExists     = errors.New("dns_record_exists")
+	ErrRecordNotFound   = errors.New("dns_record_not_found")
+	ErrRecordNotManaged = errors.New("dns_record_not_managed")
+)
+
+type NewDNSRecord struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+	Comment string `json:"comment,omitempty"`
+}
 
 type DNSListResponse struct {
 	Result []DNSRecord `json:"result"`
@@ -37,3 +54,12 @@ type CFError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
+
+func (r DNSRecord) IsManaged() bool {
+	switch r.Type {
+	case "A", "AAAA", "CNAME":
+	default:
+		return false
+	}
+	return r.Comment != nil && *r.Comment == ManagedComment
+}
@@ -0,0 +1,41 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// VerifyToken checks that the configured API token is valid and active.
+func (c *CfAPI) VerifyToken(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.cloudflare.com/client/v4/user/tokens/verify", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var verifyResp struct {
+		Success bool      `json:"success"`
+		Errors  []CFError `json:"errors"`
+		Result  struct {
+			Status string `json:"status"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&verifyResp); err != nil {
+		return fmt.Errorf("failed to parse server response: %w", err)
+	}
+
+	if !verifyResp.Success {
+		return fmt.Errorf("token verification failed: %v", verifyResp.Errors)
+	}
+	if verifyResp.Result.Status != "active" {
+		return fmt.Errorf("token is %s", verifyResp.Result.Status)
+	}
+	return nil
+}
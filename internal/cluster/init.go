@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/maintenance"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/d1manpro/nginx-proxy-api/internal/tracing"
+	"go.uber.org/zap"
+)
+
+// Cluster keeps this node in sync with the shared proxy registry. Every node
+// renders nginx configs for all proxies; the node holding the leader lease
+// additionally keeps one DNS record per healthy node for each proxy.
+type Cluster struct {
+	cfg   *config.Current
+	log   *zap.Logger
+	cf    *cloudflare.CfAPI
+	store *store.Store
+	maint *maintenance.Manager
+	stop  chan struct{}
+	done  chan struct{}
+
+	// certRetry holds, per domain, when certbot may be tried again after
+	// a failed issuance. Only the sync goroutine touches it.
+	certRetry map[string]certBackoff
+}
+
+type certBackoff struct {
+	next  time.Time
+	delay time.Duration
+}
+
+const (
+	minCertBackoff = 5 * time.Minute
+	maxCertBackoff = 6 * time.Hour
+)
+
+func New(cfg *config.Current, log *zap.Logger, cf *cloudflare.CfAPI, st *store.Store, mm *maintenance.Manager) *Cluster {
+	return &Cluster{
+		cfg:   cfg,
+		log:   log.With(zap.String("node", cfg.Get().Cluster.NodeName)),
+		cf:    cf,
+		store: st,
+		maint: mm,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+
+		certRetry: make(map[string]certBackoff),
+	}
+}
+
+func (c *Cluster) Start() {
+	interval := c.cfg.Get().Cluster.SyncInterval
+	c.log.Info("Starting cluster sync", zap.Duration("interval", interval))
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			c.sync()
+			ticker.Reset(c.cfg.Get().Cluster.SyncInterval)
+
+			select {
+			case <-ticker.C:
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Cluster) Stop() {
+	close(c.stop)
+	<-c.done
+}
+
+func (c *Cluster) sync() {
+	cfg := c.cfg.Get()
+
+	if err := c.store.Heartbeat(cfg.Cluster.NodeName, cfg.Cloudflare.NodeIP); err != nil {
+		c.log.Error("failed to send heartbeat", zap.Error(err))
+		return
+	}
+
+	ctx, span := tracing.Start(context.Background(), "cluster.sync_local")
+	c.syncLocal(ctx)
+	span.End()
+
+	leader, err := c.store.AcquireLeadership(cfg.Cluster.NodeName, cfg.Cluster.NodeTTL)
+	if err != nil {
+		c.log.Error("failed to acquire leadership", zap.Error(err))
+		return
+	}
+	if leader {
+		ctx, span = tracing.Start(context.Background(), "cluster.sync_dns")
+		c.syncDNS(ctx)
+		span.End()
+	}
+}
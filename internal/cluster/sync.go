@@ -0,0 +1,206 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
+	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
+	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"go.uber.org/zap"
+)
+
+// syncLocal renders nginx configs for proxies added on other nodes and drops
+// configs of proxies that were removed from the registry.
+func (c *Cluster) syncLocal(ctx context.Context) {
+	proxies := c.store.List()
+	wanted := make(map[string]struct{}, len(proxies))
+
+	for _, p := range proxies {
+		if p.Pending {
+			continue
+		}
+
+		fileName := p.Domain + ".conf"
+		wanted[fileName] = struct{}{}
+
+		// the node creating the proxy owns it until it is set up
+		if p.Provisioning {
+			continue
+		}
+
+		if !nginx.HasConfig(fileName) {
+			if err := c.ensureCert(ctx, p); err != nil {
+				c.log.Error("failed to prepare certificate", zap.String("domain", p.Domain), zap.Error(err))
+				continue
+			}
+
+			if err := nginx.AddConfig(ctx, p.Domain, p.CertDomain, p.Target, c.cfg.Get().NginxCfgTemplate, fileName); err != nil {
+				c.log.Error("failed to setup nginx config", zap.String("domain", p.Domain), zap.Error(err))
+				continue
+			}
+			c.log.Info("proxy synced", zap.String("domain", p.Domain))
+		}
+
+		if err := c.maint.Apply(ctx, p); err != nil {
+			c.log.Error("failed to apply maintenance mode", zap.String("domain", p.Domain), zap.Error(err))
+		}
+
+		if enabled := nginx.IsEnabled(fileName); enabled == p.Disabled {
+			var err error
+			if p.Disabled {
+				err = nginx.DisableSite(ctx, fileName)
+			} else {
+				err = nginx.EnableSite(ctx, fileName)
+			}
+			if err != nil {
+				c.log.Error("failed to toggle nginx site", zap.String("domain", p.Domain), zap.Error(err))
+			}
+		}
+	}
+
+	for domain := range c.certRetry {
+		if _, ok := wanted[domain+".conf"]; !ok {
+			delete(c.certRetry, domain)
+		}
+	}
+
+	managed, err := nginx.ListManagedConfigs()
+	if err != nil {
+		c.log.Error("failed to list nginx configs", zap.Error(err))
+		return
+	}
+	for _, fileName := range managed {
+		if _, ok := wanted[fileName]; ok {
+			continue
+		}
+		if err := nginx.RemoveConfig(ctx, fileName); err != nil {
+			c.log.Error("failed to remove nginx config", zap.String("file", fileName), zap.Error(err))
+			continue
+		}
+		c.log.Info("proxy removed", zap.String("file", fileName))
+	}
+}
+
+// ensureCert makes sure the certificate for p is on this node. Custom domains
+// are issued here; a failed issuance is not retried until its backoff, which
+// doubles on every failure, has passed, so a domain that does not resolve to
+// this node does not hit Let's Encrypt on every sync.
+func (c *Cluster) ensureCert(ctx context.Context, p store.Proxy) error {
+	exists, err := certbot.IsCertExists(ctx, p.CertDomain)
+	if err != nil {
+		return err
+	}
+	if exists {
+		delete(c.certRetry, p.Domain)
+		return nil
+	}
+	if p.ZoneID != "" {
+		return errors.New("certificate not found")
+	}
+
+	retry := c.certRetry[p.Domain]
+	if time.Now().Before(retry.next) {
+		return fmt.Errorf("certificate issuance backing off until %s", retry.next.Format(time.RFC3339))
+	}
+
+	if err := certbot.GetCert(ctx, p.Domain, c.cfg.Get().Email); err != nil {
+		retry.delay = min(max(retry.delay*2, minCertBackoff), maxCertBackoff)
+		retry.next = time.Now().Add(retry.delay)
+		c.certRetry[p.Domain] = retry
+		return err
+	}
+	delete(c.certRetry, p.Domain)
+	return nil
+}
+
+// syncDNS makes every Cloudflare-managed proxy resolve to exactly the set of
+// healthy nodes.
+func (c *Cluster) syncDNS(ctx context.Context) {
+	var ips []string
+	for _, n := range c.store.HealthyNodes(c.cfg.Get().Cluster.NodeTTL) {
+		if !slices.Contains(ips, n.IP) {
+			ips = append(ips, n.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return
+	}
+
+	for _, p := range c.store.List() {
+		// records of a proxy still being added belong to its AddProxy
+		// call, which rolls them back if it fails
+		if p.ZoneID == "" || p.Provisioning || p.Pending {
+			continue
+		}
+		if err := c.syncProxyDNS(ctx, p, ips); err != nil {
+			c.log.Error("failed to sync dns records", zap.String("domain", p.Domain), zap.Error(err))
+		}
+	}
+}
+
+func (c *Cluster) syncProxyDNS(ctx context.Context, p store.Proxy, ips []string) error {
+	records, err := c.cf.ListDNSRecords(ctx, p.ZoneID, p.Domain)
+	if err != nil {
+		return err
+	}
+
+	var keep, stale []string
+	have := make(map[string]struct{})
+	for _, r := range records {
+		if r.Type != "A" || !r.IsManaged() {
+			continue
+		}
+		if _, dup := have[r.Content]; dup || !slices.Contains(ips, r.Content) {
+			stale = append(stale, r.ID)
+			continue
+		}
+		have[r.Content] = struct{}{}
+		keep = append(keep, r.ID)
+	}
+
+	for _, ip := range ips {
+		if _, ok := have[ip]; ok {
+			continue
+		}
+		record, err := c.cf.CreateDNSRecord(ctx, p.ZoneID, cloudflare.NewDNSRecord{
+			Type:    "A",
+			Name:    p.Domain,
+			Content: ip,
+			TTL:     1,
+			Proxied: true,
+		})
+		if err != nil && !errors.Is(err, cloudflare.ErrRecordExists) {
+			return err
+		}
+		if err == nil {
+			keep = append(keep, record.ID)
+			c.log.Info("dns record added", zap.String("domain", p.Domain), zap.String("ip", ip))
+		}
+	}
+
+	if len(stale) > 0 {
+		if err := c.cf.DeleteDNSRecords(ctx, p.ZoneID, p.Domain, stale); err != nil {
+			return err
+		}
+		c.log.Info("stale dns records removed", zap.String("domain", p.Domain), zap.Int("count", len(stale)))
+	}
+
+	slices.Sort(keep)
+	if slices.Equal(keep, sortedCopy(p.DNSRecords)) {
+		return nil
+	}
+	return c.store.Update(p.Domain, func(sp *store.Proxy) {
+		sp.DNSRecords = keep
+	})
+}
+
+func sortedCopy(s []string) []string {
+	s = slices.Clone(s)
+	slices.Sort(s)
+	return s
+}
@@ -0,0 +1,26 @@
+package config
+
+import "sync/atomic"
+
+// Current holds the active config and lets a reload swap it atomically.
+// Components take a snapshot with Get at the start of an operation and use
+// it to the end, so a reload never changes settings under a running
+// certbot or Cloudflare call.
+type Current struct {
+	p atomic.Pointer[Config]
+}
+
+func NewCurrent(cfg *Config) *Current {
+	c := &Current{}
+	c.p.Store(cfg)
+	return c
+}
+
+func (c *Current) Get() *Config {
+	return c.p.Load()
+}
+
+// Swap installs cfg and returns the previous config.
+func (c *Current) Swap(cfg *Config) *Config {
+	return c.p.Swap(cfg)
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+const envPrefix = "NPA_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Precedence lists where config values come from, highest first.
+var Precedence = []string{
+	"NPA_* environment variables, or NPA_*_FILE to read the value from a file",
+	"NPA_* and NPA_*_FILE in the .env file (NPA_ENV_FILE)",
+	"config.yml (NPA_CONFIG)",
+	"built-in defaults",
+}
+
+// Override is a config key set from the environment rather than config.yml.
+type Override struct {
+	Key    string `json:"key"`
+	Source string `json:"source"`
+}
+
+// EnvFile returns the path of the .env file: NPA_ENV_FILE or .env in the
+// working directory.
+func EnvFile() string {
+	if path := os.Getenv("NPA_ENV_FILE"); path != "" {
+		return path
+	}
+	return ".env"
+}
+
+// Overrides lists the config keys the environment and the .env file set.
+// Values are not included, they may be secrets.
+func Overrides() ([]Override, error) {
+	e, err := loadEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	var p problems
+	return applyEnv(reflect.ValueOf(&cfg).Elem(), "", e, &p), nil
+}
+
+// env looks up variables in the process environment and then in the .env
+// file.
+type env struct {
+	path string
+	file map[string]string
+}
+
+func loadEnv() (*env, error) {
+	e := &env{path: EnvFile()}
+
+	file, err := godotenv.Read(e.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return e, nil
+		}
+		return nil, fmt.Errorf("%s: %w", e.path, err)
+	}
+	e.file = file
+	return e, nil
+}
+
+func (e *env) get(name string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return e.file[name]
+}
+
+// lookup returns the value for variable name, reading it from a file if
+// name_FILE is set. Source says where the value came from.
+func (e *env) lookup(name string) (value, source string, ok bool, err error) {
+	layers := []struct {
+		vars   map[string]string
+		origin string
+	}{
+		{environ(name), ""},
+		{e.file, " in " + e.path},
+	}
+
+	for _, l := range layers {
+		v, hasValue := l.vars[name]
+		file, hasFile := l.vars[name+"_FILE"]
+		switch {
+		case hasValue && hasFile:
+			return "", "", false, fmt.Errorf("set only one of %s and %s_FILE", name, name)
+		case hasValue:
+			return v, name + l.origin, true, nil
+		case hasFile:
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return "", "", false, fmt.Errorf("%s_FILE: %w", name, err)
+			}
+			return strings.TrimRight(string(data), "\r\n"), name + "_FILE" + l.origin + " (" + file + ")", true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// environ returns name and name_FILE from the process environment.
+func environ(name string) map[string]string {
+	vars := make(map[string]string)
+	for _, key := range []string{name, name + "_FILE"} {
+		if v, ok := os.LookupEnv(key); ok {
+			vars[key] = v
+		}
+	}
+	return vars
+}
+
+// applyEnv sets every field of v that has an NPA_* variable. Names follow
+// the YAML keys: cloudflare.token is NPA_CLOUDFLARE_TOKEN.
+func applyEnv(v reflect.Value, path string, e *env, p *problems) []Override {
+	var overrides []Override
+
+	t := v.Type()
+	for i := range t.NumField() {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if path != "" {
+			key = path + "." + tag
+		}
+		field := v.Field(i)
+
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			overrides = append(overrides, applyEnv(field, key, e, p)...)
+			continue
+		}
+
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		value, source, ok, err := e.lookup(name)
+		if err != nil {
+			p.add(key, "%v", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setField(field, value); err != nil {
+			p.add(key, "%s: %v", source, err)
+			continue
+		}
+		overrides = append(overrides, Override{Key: key, Source: source})
+	}
+
+	return overrides
+}
+
+// setField parses s into a field. Lists are comma-separated, maps are
+// comma-separated key=value pairs.
+func setField(field reflect.Value, s string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return errors.New("can only be set in the config file")
+		}
+		field.Set(reflect.ValueOf(splitList(s)))
+	case reflect.Map:
+		if field.Type().Elem().Kind() != reflect.String {
+			return errors.New("can only be set in the config file")
+		}
+		m := make(map[string]string)
+		for _, pair := range splitList(s) {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		field.Set(reflect.ValueOf(m))
+	default:
+		return errors.New("can only be set in the config file")
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
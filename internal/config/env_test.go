@@ -0,0 +1,273 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// load applies the .env file (given as its content) and the environment on
+// top of a YAML config, like LoadFrom does before validation.
+func load(t *testing.T, yamlText, dotenv string) (*Config, []Override, problems) {
+	t.Helper()
+
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if dotenv != "" {
+		if err := os.WriteFile(envPath, []byte(dotenv), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("NPA_ENV_FILE", envPath)
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict([]byte(yamlText), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := loadEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p problems
+	overrides := applyEnv(reflect.ValueOf(&cfg).Elem(), "", e, &p)
+	return &cfg, overrides, p
+}
+
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEnvPrecedence(t *testing.T) {
+	const yamlText = "cloudflare:\n  token: from-yaml\n"
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		dotenv string
+		want   string
+		source string
+	}{
+		{
+			name: "yaml only",
+			want: "from-yaml",
+		},
+		{
+			name:   ".env over yaml",
+			dotenv: "NPA_CLOUDFLARE_TOKEN=from-dotenv\n",
+			want:   "from-dotenv",
+			source: "NPA_CLOUDFLARE_TOKEN in ",
+		},
+		{
+			name:   "environment over .env",
+			env:    map[string]string{"NPA_CLOUDFLARE_TOKEN": "from-env"},
+			dotenv: "NPA_CLOUDFLARE_TOKEN=from-dotenv\n",
+			want:   "from-env",
+			source: "NPA_CLOUDFLARE_TOKEN",
+		},
+		{
+			name:   "environment file over .env",
+			env:    map[string]string{"NPA_CLOUDFLARE_TOKEN_FILE": "secret"},
+			dotenv: "NPA_CLOUDFLARE_TOKEN=from-dotenv\n",
+			want:   "from-file",
+			source: "NPA_CLOUDFLARE_TOKEN_FILE (",
+		},
+		{
+			name:   ".env file over yaml",
+			dotenv: "NPA_CLOUDFLARE_TOKEN_FILE=secret\n",
+			want:   "from-file",
+			source: "NPA_CLOUDFLARE_TOKEN_FILE in ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := writeSecret(t, "from-file\n")
+			for k, v := range tt.env {
+				t.Setenv(k, strings.ReplaceAll(v, "secret", secret))
+			}
+			dotenv := strings.ReplaceAll(tt.dotenv, "=secret", "="+secret)
+
+			cfg, overrides, p := load(t, yamlText, dotenv)
+			if len(p) > 0 {
+				t.Fatalf("problems: %v", p)
+			}
+			if cfg.Cloudflare.Token != tt.want {
+				t.Errorf("cloudflare.token = %q, want %q", cfg.Cloudflare.Token, tt.want)
+			}
+
+			if tt.source == "" {
+				if len(overrides) != 0 {
+					t.Errorf("overrides = %v, want none", overrides)
+				}
+				return
+			}
+			if len(overrides) != 1 || overrides[0].Key != "cloudflare.token" || !strings.HasPrefix(overrides[0].Source, tt.source) {
+				t.Errorf("overrides = %v, want cloudflare.token from %q...", overrides, tt.source)
+			}
+		})
+	}
+}
+
+func TestEnvValueAndFileConflict(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		dotenv string
+	}{
+		{
+			name: "environment",
+			env:  map[string]string{"NPA_CLOUDFLARE_TOKEN": "x", "NPA_CLOUDFLARE_TOKEN_FILE": "/dev/null"},
+		},
+		{
+			name:   ".env",
+			dotenv: "NPA_CLOUDFLARE_TOKEN=x\nNPA_CLOUDFLARE_TOKEN_FILE=/dev/null\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, _, p := load(t, "", tt.dotenv)
+			if len(p) != 1 || !strings.Contains(p[0], "set only one of NPA_CLOUDFLARE_TOKEN and NPA_CLOUDFLARE_TOKEN_FILE") {
+				t.Errorf("problems = %v, want a conflict for cloudflare.token", p)
+			}
+		})
+	}
+}
+
+func TestEnvFileValue(t *testing.T) {
+	t.Run("trailing newline is trimmed", func(t *testing.T) {
+		t.Setenv("NPA_ACCESS_TOKEN_FILE", writeSecret(t, "s3cret\r\n"))
+
+		cfg, _, p := load(t, "", "")
+		if len(p) > 0 {
+			t.Fatalf("problems: %v", p)
+		}
+		if cfg.Access.Token != "s3cret" {
+			t.Errorf("access.token = %q, want %q", cfg.Access.Token, "s3cret")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("NPA_ACCESS_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, _, p := load(t, "", "")
+		if len(p) != 1 || !strings.HasPrefix(p[0], "access.token: NPA_ACCESS_TOKEN_FILE:") {
+			t.Errorf("problems = %v, want a read error for access.token", p)
+		}
+	})
+}
+
+func TestEnvTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		value   string
+		get     func(cfg *Config) any
+		want    any
+		problem string
+	}{
+		{
+			name:  "list",
+			env:   "NPA_HTTP_SERVER_ORIGINS",
+			value: "https://a.example.com, https://b.example.com,,",
+			get:   func(cfg *Config) any { return cfg.Server.Origins },
+			want:  []string{"https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:  "empty list",
+			env:   "NPA_ACCESS_DENIED_IPS",
+			value: "",
+			get:   func(cfg *Config) any { return cfg.Access.DeniedIPs },
+			want:  []string{},
+		},
+		{
+			name:  "map",
+			env:   "NPA_CLOUDFLARE_DOMAINS",
+			value: "example.com=0123, example.org = 4567",
+			get:   func(cfg *Config) any { return cfg.Cloudflare.Domains },
+			want:  map[string]string{"example.com": "0123", "example.org": "4567"},
+		},
+		{
+			name:    "map without value",
+			env:     "NPA_CLOUDFLARE_DOMAINS",
+			value:   "example.com",
+			problem: `cloudflare.domains: NPA_CLOUDFLARE_DOMAINS: expected key=value, got "example.com"`,
+		},
+		{
+			name:  "duration",
+			env:   "NPA_RELOAD_INTERVAL",
+			value: "30s",
+			get:   func(cfg *Config) any { return cfg.Reload.Interval },
+			want:  30 * time.Second,
+		},
+		{
+			name:  "bool",
+			env:   "NPA_CLUSTER_ENABLED",
+			value: "true",
+			get:   func(cfg *Config) any { return cfg.Cluster.Enabled },
+			want:  true,
+		},
+		{
+			name:    "invalid bool",
+			env:     "NPA_CLUSTER_ENABLED",
+			value:   "yes please",
+			problem: "cluster.enabled: NPA_CLUSTER_ENABLED: ",
+		},
+		{
+			name:  "int",
+			env:   "NPA_LIMITS_MAX_PROXIES",
+			value: "5",
+			get:   func(cfg *Config) any { return cfg.Limits.MaxProxies },
+			want:  5,
+		},
+		{
+			name:    "list of structs",
+			env:     "NPA_HTTP_SERVER_TLS_CLIENT_IDENTITIES",
+			value:   "x",
+			problem: "http_server.tls.client_identities: NPA_HTTP_SERVER_TLS_CLIENT_IDENTITIES: can only be set in the config file",
+		},
+		{
+			name:    "map of lists",
+			env:     "NPA_ACCESS_JWT_SCOPE_MAP",
+			value:   "admins=admin",
+			problem: "access.jwt.scope_map: NPA_ACCESS_JWT_SCOPE_MAP: can only be set in the config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+
+			cfg, _, p := load(t, "", "")
+			if tt.problem != "" {
+				if len(p) != 1 || !strings.HasPrefix(p[0], tt.problem) {
+					t.Errorf("problems = %v, want %q", p, tt.problem)
+				}
+				return
+			}
+
+			if len(p) > 0 {
+				t.Fatalf("problems: %v", p)
+			}
+			if got := tt.get(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.env, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,28 +4,89 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
-	Server           ServerConfig `yaml:"http_server"`
-	Access           AccessConfig `yaml:"access"`
-	Cloudflare       Cloudflare   `yaml:"cloudflare"`
-	Email            string       `yaml:"email"`
-	NginxCfgTemplate string
-	DebugMode        bool `yaml:"debug_mode"`
+	Server                 ServerConfig `yaml:"http_server"`
+	Access                 AccessConfig `yaml:"access"`
+	Cloudflare             Cloudflare   `yaml:"cloudflare"`
+	Cluster                Cluster      `yaml:"cluster"`
+	HealthCheck            HealthCheck  `yaml:"health_check"`
+	Email                  string       `yaml:"email"`
+	StateFile              string       `yaml:"state_file"`
+	Maintenance            Maintenance  `yaml:"maintenance"`
+	Audit                  Audit        `yaml:"audit"`
+	Limits                 Limits       `yaml:"limits"`
+	DomainCheck            DomainCheck  `yaml:"domain_check"`
+	Onboarding             Onboarding   `yaml:"onboarding"`
+	Tracing                Tracing      `yaml:"tracing"`
+	Logging                Logging      `yaml:"logging"`
+	Reload                 Reload       `yaml:"reload"`
+	NginxCfgTemplate       string
+	MaintenanceCfgTemplate string
+	DebugMode              bool `yaml:"debug_mode"`
 }
 
 type ServerConfig struct {
-	Port    string   `yaml:"port"`
-	Host    string   `yaml:"host"`
-	Origins []string `yaml:"origins"`
+	Port           string   `yaml:"port"`
+	Host           string   `yaml:"host"`
+	Origins        []string `yaml:"origins"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	TLS            TLS      `yaml:"tls"`
+}
+
+type TLS struct {
+	CertFile          string           `yaml:"cert_file"`
+	KeyFile           string           `yaml:"key_file"`
+	ClientCAFile      string           `yaml:"client_ca_file"`
+	RequireClientCert bool             `yaml:"require_client_cert"`
+	ClientIdentities  []ClientIdentity `yaml:"client_identities"`
+}
+
+// ClientIdentity maps a verified client certificate to an API identity.
+// Subject matches either the certificate's common name or its full subject
+// (e.g. "CN=automation,O=Example").
+type ClientIdentity struct {
+	Subject string   `yaml:"subject"`
+	Name    string   `yaml:"name"`
+	Scopes  []string `yaml:"scopes"`
+	Domains []string `yaml:"domains"`
 }
 
 type AccessConfig struct {
-	Token      string   `yaml:"token"`
-	AllowedIPs []string `yaml:"allowed_ips"`
+	Token            string        `yaml:"token"`
+	TokensFile       string        `yaml:"tokens_file"`
+	AllowedIPs       []string      `yaml:"allowed_ips"`
+	DeniedIPs        []string      `yaml:"denied_ips"`
+	Lockout          Lockout       `yaml:"lockout"`
+	SignatureMaxSkew time.Duration `yaml:"signature_max_skew"`
+	JWT              JWT           `yaml:"jwt"`
+}
+
+type JWT struct {
+	JWKSURL    string              `yaml:"jwks_url"`
+	JWKSFile   string              `yaml:"jwks_file"`
+	Issuer     string              `yaml:"issuer"`
+	Audience   string              `yaml:"audience"`
+	NameClaim  string              `yaml:"name_claim"`
+	ScopeClaim string              `yaml:"scope_claim"`
+	ScopeMap   map[string][]string `yaml:"scope_map"`
+	Domains    []string            `yaml:"domains"`
+	Leeway     time.Duration       `yaml:"leeway"`
+}
+
+func (j JWT) Enabled() bool {
+	return j.JWKSURL != "" || j.JWKSFile != ""
+}
+
+type Lockout struct {
+	MaxFailures int           `yaml:"max_failures"`
+	Window      time.Duration `yaml:"window"`
+	BanDuration time.Duration `yaml:"ban_duration"`
 }
 
 type Cloudflare struct {
@@ -34,26 +95,139 @@ type Cloudflare struct {
 	Domains map[string]string `yaml:"domains"`
 }
 
+type Cluster struct {
+	Enabled      bool          `yaml:"enabled"`
+	NodeName     string        `yaml:"node_name"`
+	SyncInterval time.Duration `yaml:"sync_interval"`
+	NodeTTL      time.Duration `yaml:"node_ttl"`
+}
+
+type HealthCheck struct {
+	Interval         time.Duration `yaml:"interval"`
+	Timeout          time.Duration `yaml:"timeout"`
+	Mode             string        `yaml:"mode"`
+	HTTPPath         string        `yaml:"http_path"`
+	RequireReachable bool          `yaml:"require_reachable"`
+}
+
+// Limits are the defaults for every caller; zero means unlimited. Tokens can
+// override them individually.
+type Limits struct {
+	RequestsPerMinute int    `yaml:"requests_per_minute"`
+	Burst             int    `yaml:"burst"`
+	MaxProxies        int    `yaml:"max_proxies"`
+	MaxCertsPerWeek   int    `yaml:"max_certs_per_week"`
+	UsageFile         string `yaml:"usage_file"`
+}
+
+type DomainCheck struct {
+	Enabled    bool          `yaml:"enabled"`
+	Resolver   string        `yaml:"resolver"`
+	Timeout    time.Duration `yaml:"timeout"`
+	RequireTXT bool          `yaml:"require_txt"`
+	TXTPrefix  string        `yaml:"txt_prefix"`
+	TXTSecret  string        `yaml:"txt_secret"`
+}
+
+type Onboarding struct {
+	CheckInterval time.Duration `yaml:"check_interval"`
+	PendingTTL    time.Duration `yaml:"pending_ttl"`
+}
+
+// Logging configures the service logs. Subsystems sets levels for named
+// loggers (cloudflare, cluster, health, ...) that differ from Level.
+type Logging struct {
+	Level      string            `yaml:"level"`
+	Format     string            `yaml:"format"`
+	File       string            `yaml:"file"`
+	MaxSizeMB  int               `yaml:"max_size_mb"`
+	MaxAgeDays int               `yaml:"max_age_days"`
+	MaxBackups int               `yaml:"max_backups"`
+	Compress   bool              `yaml:"compress"`
+	Subsystems map[string]string `yaml:"subsystems"`
+}
+
+// Tracing selects where OpenTelemetry spans are sent: "otlp" (OTLP/HTTP to
+// Endpoint or the OTEL_EXPORTER_OTLP_* environment), "stdout", "file", or
+// nothing to disable tracing.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	File        string  `yaml:"file"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Reload controls watching the config and templates for changes. SIGHUP
+// reloads them regardless.
+type Reload struct {
+	Watch    bool          `yaml:"watch"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+type Audit struct {
+	File      string `yaml:"file"`
+	HashChain bool   `yaml:"hash_chain"`
+}
+
+type Maintenance struct {
+	Template      string `yaml:"template"`
+	Page          string `yaml:"page"`
+	Auto          bool   `yaml:"auto"`
+	FailThreshold int    `yaml:"fail_threshold"`
+}
+
+// Load reads the config and template from NPA_CONFIG and NPA_TEMPLATE
+// (config.yml and template.conf by default) and applies the NPA_*
+// environment overrides.
 func Load() (*Config, error) {
-	cfgPath := os.Getenv("NPA_CONFIG")
+	return LoadFrom(Paths())
+}
+
+// Paths returns the config and template paths Load uses. Both can be set in
+// the .env file too.
+func Paths() (cfgPath, tmplPath string) {
+	e, err := loadEnv()
+	if err != nil {
+		e = &env{}
+	}
+
+	cfgPath = e.get("NPA_CONFIG")
 	if cfgPath == "" {
 		cfgPath = "config.yml"
 	}
 
-	tmplPath := os.Getenv("NPA_TEMPLATE")
+	tmplPath = e.get("NPA_TEMPLATE")
 	if tmplPath == "" {
 		tmplPath = "template.conf"
 	}
+	return cfgPath, tmplPath
+}
 
+// LoadFrom reads a config, applies the environment overrides (see
+// Precedence) and validates the result. Unknown keys and values of the wrong
+// type are reported together with the problems found by Validate.
+func LoadFrom(cfgPath, tmplPath string) (*Config, error) {
 	data, err := os.ReadFile(cfgPath)
 	if err != nil {
 		return nil, err
 	}
 
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	var p problems
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%s: %w", cfgPath, err)
+		}
+		p = append(p, typeErr.Errors...)
+	}
+
+	e, err := loadEnv()
+	if err != nil {
 		return nil, err
 	}
+	applyEnv(reflect.ValueOf(&cfg).Elem(), "", e, &p)
 
 	text, err := os.ReadFile(tmplPath)
 	if err != nil {
@@ -61,12 +235,119 @@ func Load() (*Config, error) {
 	}
 	cfg.NginxCfgTemplate = string(text)
 
-	if cfg.Cloudflare.Token == "your_cloudflare_api_token" || cfg.Cloudflare.NodeIP == "0.0.0.0" {
-		return nil, fmt.Errorf("You need to configure Cloudflare API in %s", cfgPath)
+	if cfg.Maintenance.Template != "" {
+		text, err := os.ReadFile(cfg.Maintenance.Template)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MaintenanceCfgTemplate = string(text)
 	}
-	if cfg.Email == "admin@example.com" {
-		return nil, errors.New("You need to edit email")
+
+	setDefaults(&cfg)
+
+	var verr *ValidationError
+	if err := Validate(&cfg); errors.As(err, &verr) {
+		p = append(p, verr.Problems...)
+	}
+	if len(p) > 0 {
+		return nil, &ValidationError{Problems: p}
 	}
 
 	return &cfg, nil
 }
+
+func setDefaults(cfg *Config) {
+	if cfg.StateFile == "" {
+		cfg.StateFile = "proxies.json"
+	}
+	if cfg.Audit.File == "" {
+		cfg.Audit.File = "audit.log"
+	}
+	if cfg.DomainCheck.Timeout <= 0 {
+		cfg.DomainCheck.Timeout = 5 * time.Second
+	}
+	if cfg.DomainCheck.TXTPrefix == "" {
+		cfg.DomainCheck.TXTPrefix = "_npapi-verify"
+	}
+	if cfg.Onboarding.CheckInterval <= 0 {
+		cfg.Onboarding.CheckInterval = 5 * time.Minute
+	}
+	if cfg.Onboarding.PendingTTL <= 0 {
+		cfg.Onboarding.PendingTTL = 72 * time.Hour
+	}
+	if cfg.Logging.Level == "" {
+		cfg.Logging.Level = "info"
+	}
+	if cfg.Logging.Format == "" {
+		cfg.Logging.Format = "console"
+	}
+	if cfg.Logging.MaxSizeMB <= 0 {
+		cfg.Logging.MaxSizeMB = 100
+	}
+	if cfg.Logging.MaxAgeDays <= 0 {
+		cfg.Logging.MaxAgeDays = 30
+	}
+	if cfg.Tracing.File == "" {
+		cfg.Tracing.File = "traces.json"
+	}
+	if cfg.Tracing.ServiceName == "" {
+		cfg.Tracing.ServiceName = "npapi"
+	}
+	if cfg.Tracing.SampleRatio <= 0 {
+		cfg.Tracing.SampleRatio = 1
+	}
+	if cfg.Reload.Interval <= 0 {
+		cfg.Reload.Interval = 5 * time.Second
+	}
+	if cfg.Limits.UsageFile == "" {
+		cfg.Limits.UsageFile = "usage.json"
+	}
+	if cfg.Access.TokensFile == "" {
+		cfg.Access.TokensFile = "tokens.json"
+	}
+	if cfg.Access.Lockout.MaxFailures <= 0 {
+		cfg.Access.Lockout.MaxFailures = 5
+	}
+	if cfg.Access.Lockout.Window <= 0 {
+		cfg.Access.Lockout.Window = 10 * time.Minute
+	}
+	if cfg.Access.Lockout.BanDuration <= 0 {
+		cfg.Access.Lockout.BanDuration = 15 * time.Minute
+	}
+	if cfg.Access.SignatureMaxSkew <= 0 {
+		cfg.Access.SignatureMaxSkew = 5 * time.Minute
+	}
+	if cfg.Access.JWT.NameClaim == "" {
+		cfg.Access.JWT.NameClaim = "sub"
+	}
+	if cfg.Access.JWT.ScopeClaim == "" {
+		cfg.Access.JWT.ScopeClaim = "scope"
+	}
+	if cfg.Access.JWT.Leeway <= 0 {
+		cfg.Access.JWT.Leeway = time.Minute
+	}
+	if cfg.Cluster.NodeName == "" {
+		cfg.Cluster.NodeName, _ = os.Hostname()
+	}
+	if cfg.Cluster.SyncInterval <= 0 {
+		cfg.Cluster.SyncInterval = 30 * time.Second
+	}
+	if cfg.Cluster.NodeTTL <= 0 {
+		cfg.Cluster.NodeTTL = 3 * cfg.Cluster.SyncInterval
+	}
+	if cfg.HealthCheck.Interval <= 0 {
+		cfg.HealthCheck.Interval = time.Minute
+	}
+	if cfg.HealthCheck.Timeout <= 0 {
+		cfg.HealthCheck.Timeout = 5 * time.Second
+	}
+	if cfg.HealthCheck.Mode == "" {
+		cfg.HealthCheck.Mode = "tcp"
+	}
+	if cfg.HealthCheck.HTTPPath == "" {
+		cfg.HealthCheck.HTTPPath = "/"
+	}
+	if cfg.Maintenance.FailThreshold <= 0 {
+		cfg.Maintenance.FailThreshold = 3
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"go.uber.org/zap/zapcore"
+)
+
+var zoneIDRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// ValidationError lists every problem found in a config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type problems []string
+
+func (p *problems) add(field, format string, args ...any) {
+	*p = append(*p, field+": "+fmt.Sprintf(format, args...))
+}
+
+// Validate checks a loaded config, including that the nginx templates render.
+// It returns a *ValidationError with all problems found.
+func Validate(cfg *Config) error {
+	var p problems
+
+	validateServer(&p, cfg.Server)
+	validateAccess(&p, cfg.Access)
+	validateCloudflare(&p, cfg.Cloudflare)
+
+	if cfg.Email == "admin@example.com" {
+		p.add("email", "still the example address")
+	} else if _, err := mail.ParseAddress(cfg.Email); err != nil {
+		p.add("email", "invalid address %q", cfg.Email)
+	}
+
+	if cfg.Cluster.Enabled && cfg.Cluster.NodeName == "" {
+		p.add("cluster.node_name", "required when the cluster is enabled")
+	}
+
+	switch cfg.HealthCheck.Mode {
+	case "tcp", "http":
+	default:
+		p.add("health_check.mode", "must be tcp or http, got %q", cfg.HealthCheck.Mode)
+	}
+	if !strings.HasPrefix(cfg.HealthCheck.HTTPPath, "/") {
+		p.add("health_check.http_path", "must start with /")
+	}
+
+	if cfg.Limits.RequestsPerMinute < 0 || cfg.Limits.Burst < 0 || cfg.Limits.MaxProxies < 0 || cfg.Limits.MaxCertsPerWeek < 0 {
+		p.add("limits", "values must not be negative")
+	}
+
+	// there is no default: the TXT values are published, so their key must
+	// not be shared with anything else, like the API token
+	if cfg.DomainCheck.RequireTXT && cfg.DomainCheck.TXTSecret == "" {
+		p.add("domain_check.txt_secret", "required with require_txt")
+	}
+	if cfg.DomainCheck.Resolver != "" {
+		host := cfg.DomainCheck.Resolver
+		if h, port, err := net.SplitHostPort(host); err == nil {
+			host = h
+			if !validPort(port) {
+				p.add("domain_check.resolver", "invalid port %q", port)
+			}
+		}
+		if _, err := netip.ParseAddr(host); err != nil {
+			p.add("domain_check.resolver", "invalid IP address %q", host)
+		}
+	}
+
+	validateLogging(&p, cfg.Logging)
+	validateTracing(&p, cfg.Tracing)
+
+	if err := nginx.CheckTemplate(cfg.NginxCfgTemplate); err != nil {
+		p.add("template", "%v", err)
+	}
+	if cfg.MaintenanceCfgTemplate != "" {
+		if err := nginx.CheckTemplate(cfg.MaintenanceCfgTemplate); err != nil {
+			p.add("maintenance.template", "%v", err)
+		}
+	}
+	if cfg.Maintenance.Page != "" {
+		if _, err := os.Stat(cfg.Maintenance.Page); err != nil {
+			p.add("maintenance.page", "%v", err)
+		}
+	}
+
+	if len(p) > 0 {
+		return &ValidationError{Problems: p}
+	}
+	return nil
+}
+
+func validateServer(p *problems, s ServerConfig) {
+	if s.Port == "" {
+		p.add("http_server.port", "required")
+	} else if !validPort(s.Port) {
+		p.add("http_server.port", "invalid port %q", s.Port)
+	}
+	if len(s.Origins) == 0 {
+		p.add("http_server.origins", "at least one origin (or \"*\") is required")
+	}
+	validatePrefixes(p, "http_server.trusted_proxies", s.TrustedProxies)
+
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		p.add("http_server.tls", "cert_file and key_file must be set together")
+	}
+	if s.TLS.RequireClientCert && s.TLS.ClientCAFile == "" {
+		p.add("http_server.tls.client_ca_file", "required with require_client_cert")
+	}
+}
+
+func validateAccess(p *problems, a AccessConfig) {
+	validatePrefixes(p, "access.allowed_ips", a.AllowedIPs)
+	validatePrefixes(p, "access.denied_ips", a.DeniedIPs)
+
+	if a.JWT.JWKSURL != "" {
+		if u, err := url.Parse(a.JWT.JWKSURL); err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+			p.add("access.jwt.jwks_url", "invalid URL %q", a.JWT.JWKSURL)
+		}
+	}
+}
+
+func validateCloudflare(p *problems, cf Cloudflare) {
+	switch cf.Token {
+	case "":
+		p.add("cloudflare.token", "required")
+	case "your_cloudflare_api_token":
+		p.add("cloudflare.token", "still the example token")
+	}
+	if cf.NodeIP == "0.0.0.0" {
+		p.add("cloudflare.node_ip", "still the example address")
+	} else if _, err := netip.ParseAddr(cf.NodeIP); err != nil {
+		p.add("cloudflare.node_ip", "invalid IP address %q", cf.NodeIP)
+	}
+	if len(cf.Domains) == 0 {
+		p.add("cloudflare.domains", "at least one domain is required")
+	}
+	for _, domain := range slices.Sorted(maps.Keys(cf.Domains)) {
+		if zoneID := cf.Domains[domain]; !zoneIDRe.MatchString(zoneID) {
+			p.add("cloudflare.domains."+domain, "invalid zone ID %q (32 lowercase hex characters)", zoneID)
+		}
+	}
+}
+
+func validateLogging(p *problems, l Logging) {
+	if !validLevel(l.Level) {
+		p.add("logging.level", "unknown level %q", l.Level)
+	}
+	for _, name := range slices.Sorted(maps.Keys(l.Subsystems)) {
+		if level := l.Subsystems[name]; !validLevel(level) {
+			p.add("logging.subsystems."+name, "unknown level %q", level)
+		}
+	}
+	switch l.Format {
+	case "console", "json":
+	default:
+		p.add("logging.format", "must be console or json, got %q", l.Format)
+	}
+}
+
+func validateTracing(p *problems, t Tracing) {
+	switch t.Exporter {
+	case "", "otlp", "stdout", "file":
+	default:
+		p.add("tracing.exporter", "must be otlp, stdout or file, got %q", t.Exporter)
+	}
+	if t.Endpoint != "" {
+		if u, err := url.Parse(t.Endpoint); err != nil || u.Host == "" {
+			p.add("tracing.endpoint", "invalid URL %q", t.Endpoint)
+		}
+	}
+	if t.SampleRatio > 1 {
+		p.add("tracing.sample_ratio", "must be between 0 and 1")
+	}
+}
+
+// validatePrefixes accepts the same entries as auth.ParsePrefixes: single
+// IPs and CIDR ranges.
+func validatePrefixes(p *problems, field string, entries []string) {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		var err error
+		if strings.Contains(entry, "/") {
+			_, err = netip.ParsePrefix(entry)
+		} else {
+			_, err = netip.ParseAddr(entry)
+		}
+		if err != nil {
+			p.add(field, "invalid IP or CIDR %q", entry)
+		}
+	}
+}
+
+func validLevel(s string) bool {
+	_, err := zapcore.ParseLevel(s)
+	return err == nil
+}
+
+func validPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port > 0 && port <= 65535
+}
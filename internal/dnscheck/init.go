@@ -0,0 +1,161 @@
+package dnscheck
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+)
+
+// Checker verifies that a customer domain points at this node before a
+// certificate is requested for it.
+type Checker struct {
+	s atomic.Pointer[settings]
+}
+
+type settings struct {
+	cfg      config.DomainCheck
+	nodeIP   string
+	resolver *net.Resolver
+}
+
+func New(cfg config.DomainCheck, nodeIP string) *Checker {
+	c := &Checker{}
+	c.Update(cfg, nodeIP)
+	return c
+}
+
+// Update replaces the check settings, e.g. after a config reload.
+func (c *Checker) Update(cfg config.DomainCheck, nodeIP string) {
+	s := &settings{
+		cfg:      cfg,
+		nodeIP:   nodeIP,
+		resolver: net.DefaultResolver,
+	}
+
+	if cfg.Resolver != "" {
+		server := cfg.Resolver
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+
+		dialer := &net.Dialer{Timeout: cfg.Timeout}
+		s.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	c.s.Store(s)
+}
+
+// VerificationToken is the TXT value proving control over domain. It is
+// derived from the configured secret, so nothing has to be stored.
+func (c *Checker) VerificationToken(domain string) string {
+	return c.s.Load().verificationToken(domain)
+}
+
+func (s *settings) verificationToken(domain string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.TXTSecret))
+	mac.Write([]byte(domain))
+	return "npapi-verify=" + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Expected lists the records the customer has to create for domain.
+func (c *Checker) Expected(domain string) []Record {
+	return c.s.Load().expected(domain)
+}
+
+func (s *settings) expected(domain string) []Record {
+	recordType := "A"
+	if strings.Contains(s.nodeIP, ":") {
+		recordType = "AAAA"
+	}
+
+	records := []Record{{Type: recordType, Name: domain, Value: s.nodeIP}}
+	if s.cfg.RequireTXT {
+		records = append(records, Record{Type: "TXT", Name: s.txtName(domain), Value: s.verificationToken(domain)})
+	}
+	return records
+}
+
+// Check resolves domain and reports every mismatch with the expected records.
+// DNS answers like NXDOMAIN are reported as problems, not errors.
+func (c *Checker) Check(ctx context.Context, domain string) Result {
+	s := c.s.Load()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	res := Result{
+		Domain:    domain,
+		Addresses: []string{},
+		Expected:  s.expected(domain),
+	}
+
+	if cname, err := s.resolver.LookupCNAME(ctx, domain); err == nil {
+		cname = strings.TrimSuffix(cname, ".")
+		if cname != domain {
+			res.CNAME = cname
+		}
+	}
+
+	addrs, err := s.resolver.LookupHost(ctx, domain)
+	if err != nil {
+		res.Problems = append(res.Problems, "failed to resolve domain: "+lookupError(err))
+	}
+	for _, addr := range addrs {
+		res.Addresses = append(res.Addresses, addr)
+		if !sameIP(addr, s.nodeIP) {
+			res.Problems = append(res.Problems, fmt.Sprintf("%s resolves to %s, expected only %s", domain, addr, s.nodeIP))
+		}
+	}
+	if err == nil && len(addrs) == 0 {
+		res.Problems = append(res.Problems, domain+" has no A/AAAA records")
+	}
+
+	if s.cfg.RequireTXT {
+		txts, err := s.resolver.LookupTXT(ctx, s.txtName(domain))
+		if err != nil {
+			res.Problems = append(res.Problems, "failed to resolve verification TXT record: "+lookupError(err))
+		} else if !slices.Contains(txts, s.verificationToken(domain)) {
+			res.Problems = append(res.Problems, "verification TXT record not found at "+s.txtName(domain))
+		}
+	}
+
+	res.OK = len(res.Problems) == 0
+	return res
+}
+
+func (s *settings) txtName(domain string) string {
+	return s.cfg.TXTPrefix + "." + domain
+}
+
+func sameIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	return ipA != nil && ipA.Equal(ipB)
+}
+
+func lookupError(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsNotFound {
+			return "no such host"
+		}
+		if dnsErr.IsTimeout {
+			return "timeout"
+		}
+		return dnsErr.Err
+	}
+	return err.Error()
+}
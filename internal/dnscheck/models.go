@@ -0,0 +1,16 @@
+package dnscheck
+
+type Record struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Result struct {
+	Domain    string   `json:"domain"`
+	OK        bool     `json:"ok"`
+	Addresses []string `json:"addresses"`
+	CNAME     string   `json:"cname,omitempty"`
+	Problems  []string `json:"problems,omitempty"`
+	Expected  []Record `json:"expected"`
+}
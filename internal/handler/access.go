@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// domainAllowed aborts with 403 unless the caller's token may manage domain.
+func domainAllowed(c *gin.Context, domain string) bool {
+	if auth.IdentityFrom(c).AllowsDomain(domain) {
+		return true
+	}
+	apierr.Abort(c, http.StatusForbidden, apierr.CodeDomainNotAllowed, "domain not allowed for this token")
+	return false
+}
@@ -1,41 +1,75 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
 	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
 	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
 	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
 	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func AddProxy(cfg *config.Config, log *zap.Logger, cf *cloudflare.CfAPI) func(c *gin.Context) {
+func AddProxy(current *config.Current, log *zap.Logger, cf *cloudflare.CfAPI, st *store.Store, hc *health.Prober, lim *limits.Limiter, dc *dnscheck.Checker) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+		cfg := current.Get()
+
 		var req AddDomainReq
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": "invalid JSON: " + err.Error()})
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid JSON: "+err.Error())
 			return
 		}
 
 		if req.Domain == "" || req.Target == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": "domain or target is empty"})
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "domain or target is empty")
 			return
 		}
 
 		if !isDomainValid(req.Domain) {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": "domain is invalid"})
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidDomain, "domain is invalid")
 			return
 		}
 		if !isTargetValid(req.Target) {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": "target is invalid"})
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidTarget, "target is invalid")
+			return
+		}
+		if !domainAllowed(c, req.Domain) {
+			return
+		}
+		audit.Describe(c, audit.Details{Domain: req.Domain, Target: req.Target})
+
+		step(c, "quota")
+		identity := auth.IdentityFrom(c)
+		releaseProxy, err := lim.ReserveProxy(identity)
+		if err != nil {
+			apierr.Abort(c, http.StatusTooManyRequests, apierr.CodeProxyQuota, "proxy quota exceeded")
 			return
 		}
+		defer releaseProxy()
+
+		if cfg.HealthCheck.RequireReachable {
+			step(c, "health_check")
+			if status := hc.Check(req.Target); !status.Healthy {
+				apierr.Abort(c, http.StatusUnprocessableEntity, apierr.CodeTargetUnreachable, "target is unreachable: "+status.Error)
+				return
+			}
+		}
 
 		var zoneID, subdomain, certDomain string
 		for k, v := range cfg.Cloudflare.Domains {
@@ -46,67 +80,201 @@ func AddProxy(cfg *config.Config, log *zap.Logger, cf *cloudflare.CfAPI) func(c
 			}
 		}
 
+		if zoneID == "" {
+			certDomain = req.Domain
+		}
+
+		// the entry is stored first so the domain is taken while the DNS
+		// record, certificate and nginx site are set up, and cluster sync
+		// does not drop the new nginx config as unknown
+		step(c, "state")
+		by := identity.Name
+		proxy := store.Proxy{
+			Domain:       req.Domain,
+			Target:       req.Target,
+			CertDomain:   certDomain,
+			ZoneID:       zoneID,
+			CreatedAt:    time.Now(),
+			CreatedBy:    by,
+			Provisioning: true,
+		}
+		err = st.Create(proxy)
+		// from here on the store entry counts against the quota
+		releaseProxy()
+		if err != nil {
+			if errors.Is(err, store.ErrExists) {
+				apierr.Abort(c, http.StatusConflict, apierr.CodeProxyExists, "proxy already exists")
+			} else {
+				apierr.Abort(c, http.StatusInternalServerError, apierr.CodeStateFailed, "failed to save proxy state")
+				log.Error("failed to save proxy state", zap.String("domain", req.Domain), zap.Error(err))
+			}
+			return
+		}
+
+		var nginxAdded, done bool
+		defer func() {
+			if !done {
+				// the request context may already be canceled
+				rollback(context.WithoutCancel(c.Request.Context()), log, cf, st, proxy, nginxAdded)
+			}
+		}()
+
 		if zoneID != "" {
-			iCE, err := certbot.IsCertExists(certDomain)
+			step(c, "certbot")
+			iCE, err := certbot.IsCertExists(c.Request.Context(), certDomain)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "certbot error"})
+				apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCertbotFailed, "certbot error")
 				log.Error("failed to get certificates list", zap.String("domain", req.Domain), zap.Error(err))
 				return
 			}
 			if !iCE {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "certificate error"})
+				apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCertMissing, "certificate not found for "+certDomain)
 				log.Warn("certificate not found", zap.String("domain", req.Domain))
 				return
 			}
 
-			domains, err := cf.GetAllSubdomains(req.Domain, zoneID)
+			step(c, "cloudflare")
+			domains, err := cf.GetAllSubdomains(c.Request.Context(), req.Domain, zoneID)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "cloudflare error"})
+				apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCloudflareFailed, "cloudflare error")
 				log.Error("failed to get subdomain list", zap.String("domain", req.Domain), zap.Error(err))
 				return
 			}
 			if slices.Contains(domains, req.Domain) {
-				c.JSON(http.StatusConflict, gin.H{"error": "subdomain already taken"})
+				apierr.Abort(c, http.StatusConflict, apierr.CodeDNSRecordExists, "subdomain already taken")
 				return
 			}
 
-			err = cf.CreateDNSRecord(zoneID, map[string]any{
-				"type":    "A",
-				"name":    subdomain,
-				"content": cfg.Cloudflare.NodeIP,
-				"ttl":     1,
-				"proxied": true,
+			record, err := cf.CreateDNSRecord(c.Request.Context(), zoneID, cloudflare.NewDNSRecord{
+				Type:    "A",
+				Name:    subdomain,
+				Content: cfg.Cloudflare.NodeIP,
+				TTL:     1,
+				Proxied: true,
 			})
 			if err != nil {
 				if errors.Is(err, cloudflare.ErrRecordExists) {
-					c.JSON(http.StatusConflict, gin.H{"error": "subdomain already taken"})
+					apierr.Abort(c, http.StatusConflict, apierr.CodeDNSRecordExists, "subdomain already taken")
 				} else {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "cloudflare error"})
+					apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCloudflareFailed, "cloudflare error")
 					log.Error("failed to create dns record", zap.String("domain", req.Domain), zap.Error(err))
 				}
 				return
 			}
+			proxy.DNSRecords = append(proxy.DNSRecords, record.ID)
 		} else {
-			certDomain = req.Domain
-			err := certbot.GetCert(req.Domain, cfg.Email)
+			step(c, "quota")
+			cancelCert, err := lim.ReserveCert(identity)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "certbot error"})
+				if errors.Is(err, limits.ErrCertQuota) {
+					apierr.Abort(c, http.StatusTooManyRequests, apierr.CodeCertQuota, "certificate quota exceeded")
+				} else {
+					apierr.Abort(c, http.StatusInternalServerError, apierr.CodeStateFailed, "failed to save usage")
+					log.Error("failed to save usage", zap.String("domain", req.Domain), zap.Error(err))
+				}
+				return
+			}
+
+			if cfg.DomainCheck.Enabled || req.Pending {
+				step(c, "dns_check")
+				if res := dc.Check(c.Request.Context(), req.Domain); !res.OK {
+					// no certificate is issued now; for pending proxies the
+					// onboarding worker counts it when it is
+					cancelCert()
+					if req.Pending {
+						done = addPending(c, log, st, proxy, res)
+						return
+					}
+
+					apierr.AbortWithDetails(c, http.StatusUnprocessableEntity, apierr.CodeDNSCheckFailed, "domain does not point to this node", gin.H{
+						"problems": res.Problems,
+						"expected": res.Expected,
+					})
+					return
+				}
+			}
+
+			step(c, "certbot")
+			if err := certbot.GetCert(c.Request.Context(), req.Domain, cfg.Email); err != nil {
+				cancelCert()
+				apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCertbotFailed, "certbot error")
 				log.Error("failed to get certificate", zap.String("domain", req.Domain), zap.Error(err))
 				return
 			}
 		}
 
-		err := nginx.AddConfig(req.Domain, certDomain, req.Target, cfg.NginxCfgTemplate, req.Domain+".conf")
+		step(c, "nginx")
+		err = nginx.AddConfig(c.Request.Context(), req.Domain, certDomain, req.Target, cfg.NginxCfgTemplate, req.Domain+".conf")
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to setup nginx config"})
+			apierr.Abort(c, http.StatusInternalServerError, nginxCode(err), "failed to setup nginx config")
 			log.Error("failed to setup nginx config", zap.String("domain", req.Domain), zap.Error(err))
 			return
 		}
+		nginxAdded = true
 
-		c.JSON(http.StatusCreated, gin.H{"status": "created"})
+		step(c, "state")
+		proxy.Provisioning = false
+		audit.Describe(c, audit.Details{Domain: req.Domain, Target: req.Target, After: proxy})
+
+		if err := st.Put(proxy); err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeStateFailed, "failed to save proxy state")
+			log.Error("failed to save proxy state", zap.String("domain", req.Domain), zap.Error(err))
+			return
+		}
+		done = true
+
+		log.Info("proxy created", zap.String("domain", req.Domain), zap.String("target", req.Target), zap.String("by", by))
+
+		c.JSON(http.StatusCreated, StatusResp{Status: "created"})
 	}
 }
 
+// rollback undoes a proxy that failed half way through AddProxy: the nginx
+// site, the DNS records and the reserved store entry.
+func rollback(ctx context.Context, log *zap.Logger, cf *cloudflare.CfAPI, st *store.Store, p store.Proxy, nginxAdded bool) {
+	if nginxAdded {
+		if err := nginx.RemoveConfig(ctx, p.Domain+".conf"); err != nil {
+			log.Error("failed to roll back nginx config", zap.String("domain", p.Domain), zap.Error(err))
+		}
+	}
+	if p.ZoneID != "" {
+		// every managed record for the name goes, not just the ones saved
+		// in p: a create may have succeeded on Cloudflare's side without
+		// returning its ID
+		err := cf.DeleteDNSRecords(ctx, p.ZoneID, p.Domain, nil)
+		if err != nil && !errors.Is(err, cloudflare.ErrRecordNotFound) && !errors.Is(err, cloudflare.ErrRecordNotManaged) {
+			log.Error("failed to roll back dns records", zap.String("domain", p.Domain), zap.Error(err))
+		}
+	}
+	if err := st.Delete(p.Domain); err != nil {
+		log.Error("failed to roll back proxy state", zap.String("domain", p.Domain), zap.Error(err))
+	}
+}
+
+// addPending turns the reserved entry into a custom-domain proxy that waits
+// for its DNS to point at this node. The onboarding worker finishes the setup
+// later. It reports whether the entry was saved.
+func addPending(c *gin.Context, log *zap.Logger, st *store.Store, proxy store.Proxy, res dnscheck.Result) bool {
+	proxy.Provisioning = false
+	proxy.Pending = true
+	audit.Describe(c, audit.Details{Domain: proxy.Domain, Target: proxy.Target, After: proxy})
+
+	if err := st.Put(proxy); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.CodeStateFailed, "failed to save proxy state")
+		log.Error("failed to save proxy state", zap.String("domain", proxy.Domain), zap.Error(err))
+		return false
+	}
+
+	log.Info("proxy pending verification", zap.String("domain", proxy.Domain), zap.String("target", proxy.Target), zap.String("by", proxy.CreatedBy))
+
+	c.JSON(http.StatusAccepted, PendingResp{
+		Status:   "pending",
+		Problems: res.Problems,
+		Expected: res.Expected,
+	})
+	return true
+}
+
 func isDomainValid(domain string) bool {
 	re := regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)*[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$`)
 	return re.MatchString(domain)
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func ListAudit(log *zap.Logger, al *audit.Log) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		f := audit.Filter{
+			Domain: c.Query("domain"),
+			Limit:  100,
+		}
+
+		var err error
+		if v := c.Query("from"); v != "" {
+			if f.From, err = time.Parse(time.RFC3339, v); err != nil {
+				apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "from is invalid, expected RFC 3339")
+				return
+			}
+		}
+		if v := c.Query("to"); v != "" {
+			if f.To, err = time.Parse(time.RFC3339, v); err != nil {
+				apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "to is invalid, expected RFC 3339")
+				return
+			}
+		}
+		if v := c.Query("limit"); v != "" {
+			if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 {
+				apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "limit is invalid")
+				return
+			}
+		}
+
+		entries, err := al.Query(f)
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeAuditFailed, "failed to read audit log")
+			log.Error("failed to read audit log", zap.Error(err))
+			return
+		}
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+func VerifyAudit(log *zap.Logger, al *audit.Log) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		n, err := al.Verify()
+		if errors.Is(err, audit.ErrChainBroken) {
+			c.JSON(http.StatusOK, AuditVerifyResp{Valid: false, Line: n})
+			return
+		}
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeAuditFailed, "failed to read audit log")
+			log.Error("failed to verify audit log", zap.Error(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AuditVerifyResp{Valid: true, Entries: n})
+	}
+}
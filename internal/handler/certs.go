@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func ListCerts(log *zap.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		certs, err := certbot.ListCerts()
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCertbotFailed, "failed to read certificates")
+			log.Error("failed to read certificates", zap.Error(err))
+			return
+		}
+		// same rule as RenewCert: a certificate is visible if its name is
+		// one of the caller's domains
+		identity := auth.IdentityFrom(c)
+		resp := make([]certbot.Cert, 0, len(certs))
+		for _, cert := range certs {
+			if identity.AllowsDomain(cert.Name) {
+				resp = append(resp, cert)
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// RenewCert renews a certificate through certbot; ?force=true renews it even
+// if it is not due yet.
+func RenewCert(log *zap.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		name := c.Param("name")
+		if !isDomainValid(name) {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidDomain, "certificate name is invalid")
+			return
+		}
+		if !domainAllowed(c, name) {
+			return
+		}
+		audit.Describe(c, audit.Details{Domain: name})
+
+		force := c.Query("force") == "true"
+		if err := certbot.RenewCert(c.Request.Context(), name, force); err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCertbotFailed, "certbot error")
+			log.Error("failed to renew certificate", zap.String("domain", name), zap.Error(err))
+			return
+		}
+
+		log.Info("certificate renewed", zap.String("domain", name), zap.Bool("force", force), zap.String("by", auth.IdentityFrom(c).Name))
+
+		c.JSON(http.StatusOK, StatusResp{Status: "renewed"})
+	}
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/gin-gonic/gin"
+)
+
+// CheckDomain shows how a custom domain currently resolves and which
+// records it needs before a proxy can be created for it.
+func CheckDomain(dc *dnscheck.Checker) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		domain := c.Param("domain")
+		if !isDomainValid(domain) {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidDomain, "domain is invalid")
+			return
+		}
+		if !domainAllowed(c, domain) {
+			return
+		}
+
+		c.JSON(http.StatusOK, dc.Check(c.Request.Context(), domain))
+	}
+}
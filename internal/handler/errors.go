@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+)
+
+// nginxCode tells a failed config test or reload apart from other nginx
+// errors such as unwritable site directories.
+func nginxCode(err error) string {
+	switch {
+	case errors.Is(err, nginx.ErrTestFailed):
+		return apierr.CodeNginxTestFailed
+	case errors.Is(err, nginx.ErrReloadFailed):
+		return apierr.CodeNginxReloadFailed
+	default:
+		return apierr.CodeNginxFailed
+	}
+}
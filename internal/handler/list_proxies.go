@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+func ListProxies(st *store.Store, hc *health.Prober) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		proxies := st.List()
+		identity := auth.IdentityFrom(c)
+
+		resp := make([]ProxyResp, 0, len(proxies))
+		for _, p := range proxies {
+			if identity.AllowsDomain(p.Domain) {
+				resp = append(resp, newProxyResp(p, hc))
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func ProxyHealth(st *store.Store, hc *health.Prober) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		domain := c.Param("domain")
+		if !domainAllowed(c, domain) {
+			return
+		}
+
+		p, ok := st.Get(domain)
+		if !ok {
+			apierr.Abort(c, http.StatusNotFound, apierr.CodeProxyNotFound, "proxy not found")
+			return
+		}
+
+		status, ok := hc.Status(domain)
+		if !ok {
+			status = hc.Probe(p.Domain, p.Target)
+		}
+
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+func newProxyResp(p store.Proxy, hc *health.Prober) ProxyResp {
+	resp := ProxyResp{
+		Proxy:   p,
+		Enabled: !p.Disabled,
+	}
+	if status, ok := hc.Status(p.Domain); ok {
+		resp.Health = &status
+	}
+	return resp
+}
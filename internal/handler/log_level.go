@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/logging"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func GetLogLevel(logs *logging.Logs) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, logs.Levels())
+	}
+}
+
+func SetLogLevel(log *zap.Logger, logs *logging.Logs) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		var req LogLevelReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid JSON: "+err.Error())
+			return
+		}
+
+		if req.Subsystem == "" && req.Level == "" {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "level is empty")
+			return
+		}
+
+		before := logs.Levels()
+		if err := logs.SetLevel(req.Subsystem, req.Level); err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, err.Error())
+			return
+		}
+		after := logs.Levels()
+		audit.Describe(c, audit.Details{Before: before, After: after})
+
+		log.Info("log level changed",
+			zap.String("subsystem", req.Subsystem),
+			zap.String("level", req.Level),
+			zap.String("by", auth.IdentityFrom(c).Name),
+		)
+
+		c.JSON(http.StatusOK, after)
+	}
+}
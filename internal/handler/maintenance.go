@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/maintenance"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func SetMaintenance(log *zap.Logger, st *store.Store, mm *maintenance.Manager) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		domain := c.Param("domain")
+		if !domainAllowed(c, domain) {
+			return
+		}
+
+		var req MaintenanceReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		if req.Enabled == nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "enabled is empty")
+			return
+		}
+
+		before, _ := st.Get(domain)
+		if before.Pending {
+			apierr.Abort(c, http.StatusConflict, apierr.CodeProxyPending, "proxy is pending verification")
+			return
+		}
+
+		if _, err := mm.Set(c.Request.Context(), domain, *req.Enabled, false); err != nil {
+			if errors.Is(err, maintenance.ErrProxyNotFound) {
+				apierr.Abort(c, http.StatusNotFound, apierr.CodeProxyNotFound, "proxy not found")
+				return
+			}
+			apierr.Abort(c, http.StatusInternalServerError, nginxCode(err), "failed to setup nginx config")
+			log.Error("failed to switch maintenance mode", zap.String("domain", domain), zap.Error(err))
+			return
+		}
+
+		after, _ := st.Get(domain)
+		audit.Describe(c, audit.Details{Domain: domain, Target: before.Target, Before: before, After: after})
+
+		log.Info("maintenance mode switched", zap.String("domain", domain), zap.Bool("maintenance", *req.Enabled), zap.String("by", auth.IdentityFrom(c).Name))
+
+		c.JSON(http.StatusOK, MaintenanceResp{Maintenance: *req.Enabled})
+	}
+}
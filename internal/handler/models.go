@@ -1,10 +1,97 @@
 package handler
 
+import (
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+)
+
 type AddDomainReq struct {
-	Domain string `json:"domain"`
-	Target string `json:"target"`
+	Domain  string `json:"domain"`
+	Target  string `json:"target"`
+	Pending bool   `json:"pending"`
 }
 
 type RemoveDomainReq struct {
 	Domain string `json:"domain"`
 }
+
+type MaintenanceReq struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type StatusResp struct {
+	Status string `json:"status"`
+}
+
+// PendingResp is returned for a custom domain accepted as pending, with the
+// records it still needs.
+type PendingResp struct {
+	Status   string            `json:"status"`
+	Problems []string          `json:"problems"`
+	Expected []dnscheck.Record `json:"expected"`
+}
+
+type EnabledResp struct {
+	Enabled bool `json:"enabled"`
+}
+
+type MaintenanceResp struct {
+	Maintenance bool `json:"maintenance"`
+}
+
+// AuditVerifyResp reports either the number of valid entries or the line
+// where the hash chain breaks.
+type AuditVerifyResp struct {
+	Valid   bool `json:"valid"`
+	Entries int  `json:"entries,omitempty"`
+	Line    int  `json:"line,omitempty"`
+}
+
+type ProxyResp struct {
+	store.Proxy
+	Enabled bool           `json:"enabled"`
+	Health  *health.Status `json:"health,omitempty"`
+}
+
+type CreateTokenReq struct {
+	Name      string       `json:"name"`
+	Scheme    string       `json:"scheme"`
+	Scopes    []string     `json:"scopes"`
+	Domains   []string     `json:"domains"`
+	ExpiresAt *time.Time   `json:"expires_at"`
+	Limits    *auth.Limits `json:"limits"`
+}
+
+type TokenResp struct {
+	Name      string       `json:"name"`
+	Token     string       `json:"token,omitempty"`
+	Scheme    string       `json:"scheme"`
+	Scopes    []string     `json:"scopes"`
+	Domains   []string     `json:"domains,omitempty"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	LastUsed  *time.Time   `json:"last_used,omitempty"`
+	Limits    *auth.Limits `json:"limits,omitempty"`
+}
+
+type ReadyResp struct {
+	Status string               `json:"status"`
+	Checks map[string]CheckResp `json:"checks"`
+}
+
+type CheckResp struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// LogLevelReq changes the root level, or a subsystem's level when Subsystem
+// is set. An empty Level for a subsystem goes back to the root level.
+type LogLevelReq struct {
+	Subsystem string `json:"subsystem"`
+	Level     string `json:"level"`
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
+	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
+	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Check results are cached so that unauthenticated probes neither start an
+// nginx or certbot process per request nor spend the Cloudflare rate limit.
+// Failures are retried sooner, so a node recovers quickly.
+const (
+	localCheckTTL          = 10 * time.Second
+	cloudflareCheckTTL     = time.Minute
+	checkFailTTL           = 10 * time.Second
+	cloudflareCheckTimeout = 10 * time.Second
+)
+
+func Healthz() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, StatusResp{Status: "ok"})
+	}
+}
+
+// Readyz is served without authentication, so failed checks only report a
+// generic message and the error itself is logged.
+func Readyz(log *zap.Logger, cf *cloudflare.CfAPI) func(c *gin.Context) {
+	nginxCheck := &cachedCheck{ttl: localCheckTTL}
+	dirsCheck := &cachedCheck{ttl: localCheckTTL}
+	certbotCheck := &cachedCheck{ttl: localCheckTTL}
+	cfCheck := &cachedCheck{ttl: cloudflareCheckTTL}
+
+	return func(c *gin.Context) {
+		checks := map[string]CheckResp{
+			"nginx": nginxCheck.get(func() CheckResp {
+				return runCheck(log, "nginx", "nginx config test failed", nginx.CheckConfig)
+			}),
+			"nginx_dirs": dirsCheck.get(func() CheckResp {
+				return runCheck(log, "nginx_dirs", "nginx sites directories are not writable", nginx.CheckDirs)
+			}),
+			"certbot": certbotCheck.get(func() CheckResp {
+				return runCheck(log, "certbot", "certbot is not installed", certbot.CheckInstalled)
+			}),
+			"cloudflare": cfCheck.get(func() CheckResp {
+				// not the request context: the result is shared with other
+				// probes and must not fail because one caller went away
+				ctx, cancel := context.WithTimeout(context.Background(), cloudflareCheckTimeout)
+				defer cancel()
+				return runCheck(log, "cloudflare", "cloudflare token check failed", func() error {
+					return cf.VerifyToken(ctx)
+				})
+			}),
+		}
+
+		status, code := "ok", http.StatusOK
+		for _, check := range checks {
+			if !check.OK {
+				status, code = "fail", http.StatusServiceUnavailable
+			}
+		}
+
+		c.JSON(code, ReadyResp{Status: status, Checks: checks})
+	}
+}
+
+// cachedCheck runs a check at most once at a time and reuses a successful
+// result for ttl, a failed one for at most checkFailTTL. Callers that arrive
+// while it runs get the previous result instead of waiting.
+type cachedCheck struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	result    CheckResp
+	checkedAt time.Time
+	running   bool
+}
+
+func (cc *cachedCheck) get(fn func() CheckResp) CheckResp {
+	cc.mu.Lock()
+	ttl := cc.ttl
+	if !cc.result.OK {
+		ttl = min(ttl, checkFailTTL)
+	}
+	if cc.running || time.Since(cc.checkedAt) <= ttl {
+		result := cc.result
+		cc.mu.Unlock()
+		if result == (CheckResp{}) {
+			result.Error = "check in progress"
+		}
+		return result
+	}
+	cc.running = true
+	cc.mu.Unlock()
+
+	result := fn()
+
+	cc.mu.Lock()
+	cc.result, cc.checkedAt, cc.running = result, time.Now(), false
+	cc.mu.Unlock()
+	return result
+}
+
+func runCheck(log *zap.Logger, name, msg string, fn func() error) CheckResp {
+	start := time.Now()
+	err := fn()
+
+	resp := CheckResp{OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		resp.Error = msg
+		log.Warn("readiness check failed", zap.String("check", name), zap.Error(err))
+	}
+	return resp
+}
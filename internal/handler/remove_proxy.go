@@ -1,40 +1,58 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
 	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
 	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
 	"github.com/d1manpro/nginx-proxy-api/internal/config"
 	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func RemoveProxy(cfg *config.Config, log *zap.Logger, cf *cloudflare.CfAPI) func(c *gin.Context) {
+func RemoveProxy(current *config.Current, log *zap.Logger, cf *cloudflare.CfAPI, st *store.Store) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+		cfg := current.Get()
+
 		var req RemoveDomainReq
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": "invalid JSON: " + err.Error()})
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid JSON: "+err.Error())
 			return
 		}
 
 		if req.Domain == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": "domain is empty"})
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "domain is empty")
 			return
 		}
 
 		if !isDomainValid(req.Domain) {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": "domain is invalid"})
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidDomain, "domain is invalid")
+			return
+		}
+		if !domainAllowed(c, req.Domain) {
 			return
 		}
 
+		// proxies added before the state file existed are not in it; they
+		// are still removed, matching their dns records by name
+		proxy, known := st.Get(req.Domain)
+		audit.Describe(c, audit.Details{Domain: req.Domain, Target: proxy.Target, Before: proxy})
+
+		step(c, "nginx")
 		nginxCfgPath := req.Domain + ".conf"
 
-		err := nginx.RemoveConfig(nginxCfgPath)
+		err := nginx.RemoveConfig(c.Request.Context(), nginxCfgPath)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to setup nginx config"})
+			apierr.Abort(c, http.StatusInternalServerError, nginxCode(err), "failed to setup nginx config")
 			log.Error("failed to setup nginx config", zap.String("domain", req.Domain), zap.Error(err))
 			return
 		}
@@ -47,21 +65,42 @@ func RemoveProxy(cfg *config.Config, log *zap.Logger, cf *cloudflare.CfAPI) func
 		}
 
 		if zoneID != "" {
-			err := cf.DeleteDNSRecord(zoneID, req.Domain)
-			if err != nil {
-				c.JSON(http.StatusNoContent, gin.H{"status": "deleted"})
+			var recordIDs []string
+			if known && !cfg.Cluster.Enabled {
+				// in cluster mode other nodes' records may have been added
+				// since the last sync, so every managed record for the name
+				// is matched instead
+				recordIDs = proxy.DNSRecords
+			}
+
+			step(c, "cloudflare")
+			err := cf.DeleteDNSRecords(c.Request.Context(), zoneID, req.Domain, recordIDs)
+			if errors.Is(err, cloudflare.ErrRecordNotManaged) {
+				log.Warn("kept dns records not created by npapi", zap.String("domain", req.Domain), zap.Error(err))
+			} else if err != nil && !errors.Is(err, cloudflare.ErrRecordNotFound) {
+				apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCloudflareFailed, "cloudflare error")
 				log.Error("failed to delete cloudflare record", zap.String("domain", req.Domain), zap.Error(err))
 				return
 			}
-		} else {
-			err = certbot.DeleteCert(req.Domain)
+		} else if !proxy.Pending {
+			step(c, "certbot")
+			err = certbot.DeleteCert(c.Request.Context(), req.Domain)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "certbot error"})
+				apierr.Abort(c, http.StatusInternalServerError, apierr.CodeCertbotFailed, "certbot error")
 				log.Error("failed to get certificate", zap.String("domain", req.Domain), zap.Error(err))
 				return
 			}
 		}
 
+		step(c, "state")
+		if err := st.Delete(req.Domain); err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeStateFailed, "failed to save proxy state")
+			log.Error("failed to save proxy state", zap.String("domain", req.Domain), zap.Error(err))
+			return
+		}
+
+		log.Info("proxy removed", zap.String("domain", req.Domain), zap.String("by", auth.IdentityFrom(c).Name))
+
 		c.Status(http.StatusNoContent)
 	}
 }
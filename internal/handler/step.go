@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// step marks the provisioning step a handler is entering, for error
+// responses, the failure step metric and as a trace span.
+func step(c *gin.Context, name string) {
+	apierr.SetStep(c, name)
+	tracing.Step(c, name)
+}
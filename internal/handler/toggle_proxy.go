@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ToggleProxy links or unlinks the proxy's nginx site. Config, DNS records
+// and certificates are left in place.
+func ToggleProxy(log *zap.Logger, st *store.Store, enable bool) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		domain := c.Param("domain")
+		if !domainAllowed(c, domain) {
+			return
+		}
+
+		before, ok := st.Get(domain)
+		if !ok {
+			apierr.Abort(c, http.StatusNotFound, apierr.CodeProxyNotFound, "proxy not found")
+			return
+		}
+		if before.Pending {
+			apierr.Abort(c, http.StatusConflict, apierr.CodeProxyPending, "proxy is pending verification")
+			return
+		}
+		after := before
+		after.Disabled = !enable
+		audit.Describe(c, audit.Details{Domain: domain, Target: before.Target, Before: before, After: after})
+
+		// the state is written first so that a failure there leaves nginx
+		// untouched; cluster sync applies it on the other nodes
+		step(c, "state")
+		err := st.Update(domain, func(p *store.Proxy) {
+			p.Disabled = !enable
+		})
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeStateFailed, "failed to save proxy state")
+			log.Error("failed to save proxy state", zap.String("domain", domain), zap.Error(err))
+			return
+		}
+
+		step(c, "nginx")
+		if enable {
+			err = nginx.EnableSite(c.Request.Context(), domain+".conf")
+		} else {
+			err = nginx.DisableSite(c.Request.Context(), domain+".conf")
+		}
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, nginxCode(err), "failed to setup nginx config")
+			log.Error("failed to toggle nginx site", zap.String("domain", domain), zap.Bool("enable", enable), zap.Error(err))
+
+			err = st.Update(domain, func(p *store.Proxy) {
+				p.Disabled = before.Disabled
+			})
+			if err != nil {
+				log.Error("failed to roll back proxy state", zap.String("domain", domain), zap.Error(err))
+			}
+			return
+		}
+
+		log.Info("proxy toggled", zap.String("domain", domain), zap.Bool("enabled", enable), zap.String("by", auth.IdentityFrom(c).Name))
+
+		c.JSON(http.StatusOK, EnabledResp{Enabled: enable})
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func ListTokens(tokens *auth.Tokens) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		list := tokens.List()
+
+		resp := make([]TokenResp, 0, len(list))
+		for _, t := range list {
+			resp = append(resp, newTokenResp(t))
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func CreateToken(log *zap.Logger, tokens *auth.Tokens) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		var req CreateTokenReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid JSON: "+err.Error())
+			return
+		}
+
+		if req.Name == "" || len(req.Scopes) == 0 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "name or scopes is empty")
+			return
+		}
+		for _, scope := range req.Scopes {
+			if !slices.Contains(auth.Scopes, scope) {
+				apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "unknown scope: "+scope)
+				return
+			}
+		}
+		switch req.Scheme {
+		case "":
+			req.Scheme = auth.SchemeBearer
+		case auth.SchemeBearer, auth.SchemeHMAC:
+		default:
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "unknown scheme: "+req.Scheme)
+			return
+		}
+		for _, domain := range req.Domains {
+			if !isDomainValid(domain) {
+				apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidDomain, "domain is invalid: "+domain)
+				return
+			}
+		}
+		if l := req.Limits; l != nil && (l.RequestsPerMinute < 0 || l.MaxProxies < 0 || l.MaxCertsPerWeek < 0) {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "limits must not be negative")
+			return
+		}
+
+		raw, tok, err := tokens.Create(req.Name, req.Scheme, req.Scopes, req.Domains, req.ExpiresAt, req.Limits)
+		if err != nil {
+			if errors.Is(err, auth.ErrTokenExists) {
+				apierr.Abort(c, http.StatusConflict, apierr.CodeTokenExists, "token name already taken")
+				return
+			}
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeTokenFailed, "failed to create token")
+			log.Error("failed to create token", zap.String("name", req.Name), zap.Error(err))
+			return
+		}
+
+		log.Info("token created", zap.String("name", req.Name), zap.Strings("scopes", req.Scopes), zap.String("by", auth.IdentityFrom(c).Name))
+
+		resp := newTokenResp(tok)
+		audit.Describe(c, audit.Details{After: resp})
+
+		resp.Token = raw
+		c.JSON(http.StatusCreated, resp)
+	}
+}
+
+func RevokeToken(log *zap.Logger, tokens *auth.Tokens) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		log := log.With(requestid.Field(c))
+
+		name := c.Param("name")
+		audit.Describe(c, audit.Details{Before: gin.H{"name": name}})
+
+		if err := tokens.Revoke(name); err != nil {
+			if errors.Is(err, auth.ErrTokenNotFound) {
+				apierr.Abort(c, http.StatusNotFound, apierr.CodeTokenNotFound, "token not found")
+				return
+			}
+			apierr.Abort(c, http.StatusInternalServerError, apierr.CodeTokenFailed, "failed to revoke token")
+			log.Error("failed to revoke token", zap.String("name", name), zap.Error(err))
+			return
+		}
+
+		log.Info("token revoked", zap.String("name", name), zap.String("by", auth.IdentityFrom(c).Name))
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func newTokenResp(t auth.Token) TokenResp {
+	scheme := t.Scheme
+	if scheme == "" {
+		scheme = auth.SchemeBearer
+	}
+
+	return TokenResp{
+		Name:      t.Name,
+		Scheme:    scheme,
+		Scopes:    t.Scopes,
+		Domains:   t.Domains,
+		ExpiresAt: t.ExpiresAt,
+		CreatedAt: t.CreatedAt,
+		LastUsed:  t.LastUsed,
+		Limits:    t.Limits,
+	}
+}
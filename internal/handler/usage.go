@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
+	"github.com/gin-gonic/gin"
+)
+
+// Usage reports the caller's rate limit and quota usage. Admins can look
+// at another token with ?token=<name>.
+func Usage(tokens *auth.Tokens, lim *limits.Limiter) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		identity := auth.IdentityFrom(c)
+
+		if name := c.Query("token"); name != "" && name != identity.Name {
+			if !identity.HasScope(auth.ScopeAdmin) {
+				apierr.Abort(c, http.StatusForbidden, apierr.CodeMissingScope, "missing scope "+auth.ScopeAdmin)
+				return
+			}
+
+			var ok bool
+			identity, ok = tokens.Lookup(name)
+			if !ok {
+				apierr.Abort(c, http.StatusNotFound, apierr.CodeTokenNotFound, "token not found")
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, lim.Usage(identity))
+	}
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+)
+
+// Check probes target once using the configured mode ("tcp" or "http").
+func (p *Prober) Check(target string) Status {
+	start := time.Now()
+	hc := p.cfg.Get().HealthCheck
+
+	var err error
+	switch hc.Mode {
+	case "http":
+		err = checkHTTP(target, hc)
+	default:
+		err = checkTCP(target, hc)
+	}
+
+	st := Status{
+		Healthy:   err == nil,
+		CheckedAt: start,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	return st
+}
+
+func checkTCP(target string, hc config.HealthCheck) error {
+	conn, err := net.DialTimeout("tcp", target, hc.Timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func checkHTTP(target string, hc config.HealthCheck) error {
+	client := &http.Client{
+		Timeout: hc.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get("http://" + target + hc.HTTPPath)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"sync"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"go.uber.org/zap"
+)
+
+// maxParallel limits how many targets are probed at the same time.
+const maxParallel = 10
+
+// Prober periodically checks that proxy targets are reachable and keeps the
+// last result for each domain in memory.
+type Prober struct {
+	cfg   *config.Current
+	log   *zap.Logger
+	store *store.Store
+
+	mu      sync.RWMutex
+	results map[string]Status
+	hooks   []func(domain string, st Status)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewProber(cfg *config.Current, log *zap.Logger, st *store.Store) *Prober {
+	return &Prober{
+		cfg:     cfg,
+		log:     log,
+		store:   st,
+		results: make(map[string]Status),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// OnResult registers fn to be called after every probe. It must be called
+// before Start.
+func (p *Prober) OnResult(fn func(domain string, st Status)) {
+	p.hooks = append(p.hooks, fn)
+}
+
+func (p *Prober) Start() {
+	interval := p.cfg.Get().HealthCheck.Interval
+	p.log.Info("Starting health checks", zap.Duration("interval", interval))
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			p.probeAll()
+			// the interval may have changed with a config reload
+			ticker.Reset(p.cfg.Get().HealthCheck.Interval)
+
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (p *Prober) Stop() {
+	close(p.stop)
+	<-p.done
+}
+
+// Status returns the last known health of domain.
+func (p *Prober) Status(domain string) (Status, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	st, ok := p.results[domain]
+	return st, ok
+}
+
+// Probe checks domain's target right away and stores the result.
+func (p *Prober) Probe(domain, target string) Status {
+	st := p.Check(target)
+
+	p.mu.Lock()
+	prev, seen := p.results[domain]
+	if !st.Healthy {
+		st.Failures = prev.Failures + 1
+	}
+	p.results[domain] = st
+	p.mu.Unlock()
+
+	if seen && prev.Healthy != st.Healthy {
+		if st.Healthy {
+			p.log.Info("target is back up", zap.String("domain", domain), zap.String("target", target))
+		} else {
+			p.log.Warn("target is down", zap.String("domain", domain), zap.String("target", target), zap.String("error", st.Error))
+		}
+	}
+
+	for _, fn := range p.hooks {
+		fn(domain, st)
+	}
+
+	return st
+}
+
+func (p *Prober) probeAll() {
+	proxies := p.store.List()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallel)
+	for _, proxy := range proxies {
+		if proxy.Pending {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.Probe(proxy.Domain, proxy.Target)
+		}()
+	}
+	wg.Wait()
+
+	known := make(map[string]struct{}, len(proxies))
+	for _, proxy := range proxies {
+		known[proxy.Domain] = struct{}{}
+	}
+
+	p.mu.Lock()
+	for domain := range p.results {
+		if _, ok := known[domain]; !ok {
+			delete(p.results, domain)
+		}
+	}
+	p.mu.Unlock()
+}
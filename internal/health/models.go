@@ -0,0 +1,11 @@
+package health
+
+import "time"
+
+type Status struct {
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Failures  int       `json:"consecutive_failures"`
+}
@@ -0,0 +1,244 @@
+package limits
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+)
+
+const week = 7 * 24 * time.Hour
+
+// Limiter enforces per-identity request rates and quotas. Proxy counts come
+// from the store; certificate issuances are kept in the usage file so weekly
+// quotas survive restarts.
+type Limiter struct {
+	cfg   atomic.Pointer[config.Limits]
+	store *store.Store
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	certs   map[string][]time.Time
+	// proxyHolds counts proxies being created that are not in the store yet
+	proxyHolds map[string]int
+}
+
+func New(cfg config.Limits, st *store.Store) (*Limiter, error) {
+	l := &Limiter{
+		store:      st,
+		buckets:    make(map[string]*bucket),
+		certs:      make(map[string][]time.Time),
+		proxyHolds: make(map[string]int),
+	}
+	l.cfg.Store(&cfg)
+
+	data, err := os.ReadFile(cfg.UsageFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, fmt.Errorf("failed to read usage file: %w", err)
+	}
+	if err := json.Unmarshal(data, &l.certs); err != nil {
+		return nil, fmt.Errorf("failed to parse usage file: %w", err)
+	}
+
+	return l, nil
+}
+
+// Update replaces the default limits. The usage file stays the same until a
+// restart.
+func (l *Limiter) Update(cfg config.Limits) {
+	cfg.UsageFile = l.cfg.Load().UsageFile
+	l.cfg.Store(&cfg)
+}
+
+// Allow takes one request from the identity's token bucket. When the bucket
+// is empty it returns false and how long to wait.
+func (l *Limiter) Allow(id auth.Identity) (bool, time.Duration) {
+	rpm := l.effective(id).RequestsPerMinute
+	if rpm <= 0 {
+		return true, 0
+	}
+	burst := max(l.cfg.Load().Burst, 1)
+	rate := float64(rpm) / 60
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[id.Name]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		l.buckets[id.Name] = b
+	}
+
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// ReserveProxy fails if the identity already owns its maximum number of
+// proxies. Otherwise it holds a slot until release is called, which the
+// caller does once the proxy is in the store or has failed, so concurrent
+// requests cannot both take the last slot.
+func (l *Limiter) ReserveProxy(id auth.Identity) (release func(), err error) {
+	limit := l.effective(id).MaxProxies
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit > 0 && l.proxyCount(id.Name)+l.proxyHolds[id.Name] >= limit {
+		return nil, ErrProxyQuota
+	}
+	l.proxyHolds[id.Name]++
+
+	return sync.OnceFunc(func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.proxyHolds[id.Name]--; l.proxyHolds[id.Name] <= 0 {
+			delete(l.proxyHolds, id.Name)
+		}
+	}), nil
+}
+
+// ReserveCert fails if the identity used up this week's certificate
+// issuances. Otherwise it counts the issuance right away; the caller calls
+// cancel if the certificate could not be issued.
+func (l *Limiter) ReserveCert(id auth.Identity) (cancel func(), err error) {
+	limit := l.effective(id).MaxCertsPerWeek
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := l.recentCerts(id.Name)
+	if limit > 0 && len(recent) >= limit {
+		return nil, ErrCertQuota
+	}
+
+	at := time.Now()
+	l.certs[id.Name] = append(recent, at)
+	if err := l.save(); err != nil {
+		l.certs[id.Name] = recent
+		return nil, err
+	}
+
+	return sync.OnceFunc(func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		times := l.certs[id.Name]
+		if i := slices.Index(times, at); i >= 0 {
+			l.certs[id.Name] = slices.Delete(times, i, i+1)
+			l.save()
+		}
+	}), nil
+}
+
+func (l *Limiter) Usage(id auth.Identity) Usage {
+	eff := l.effective(id)
+
+	l.mu.Lock()
+	certs := len(l.recentCerts(id.Name))
+	l.mu.Unlock()
+
+	return Usage{
+		Identity:          id.Name,
+		RequestsPerMinute: eff.RequestsPerMinute,
+		Proxies:           l.proxyCount(id.Name),
+		MaxProxies:        eff.MaxProxies,
+		CertsThisWeek:     certs,
+		MaxCertsPerWeek:   eff.MaxCertsPerWeek,
+	}
+}
+
+func (l *Limiter) effective(id auth.Identity) auth.Limits {
+	cfg := l.cfg.Load()
+	eff := auth.Limits{
+		RequestsPerMinute: cfg.RequestsPerMinute,
+		MaxProxies:        cfg.MaxProxies,
+		MaxCertsPerWeek:   cfg.MaxCertsPerWeek,
+	}
+	if id.Limits == nil {
+		return eff
+	}
+
+	// negative values are rejected when tokens are created; ignore any
+	// that made it into the tokens file
+	if id.Limits.RequestsPerMinute > 0 {
+		eff.RequestsPerMinute = id.Limits.RequestsPerMinute
+	}
+	if id.Limits.MaxProxies > 0 {
+		eff.MaxProxies = id.Limits.MaxProxies
+	}
+	if id.Limits.MaxCertsPerWeek > 0 {
+		eff.MaxCertsPerWeek = id.Limits.MaxCertsPerWeek
+	}
+	return eff
+}
+
+func (l *Limiter) proxyCount(name string) int {
+	n := 0
+	for _, p := range l.store.List() {
+		if p.CreatedBy == name {
+			n++
+		}
+	}
+	return n
+}
+
+// recentCerts drops issuances older than a week. Callers must hold l.mu.
+func (l *Limiter) recentCerts(name string) []time.Time {
+	times := l.certs[name]
+	cutoff := time.Now().Add(-week)
+
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	times = times[i:]
+	l.certs[name] = times
+	return times
+}
+
+func (l *Limiter) save() error {
+	data, err := json.MarshalIndent(l.certs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	path := l.cfg.Load().UsageFile
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write usage: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write usage: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace usage file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package limits
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrProxyQuota = errors.New("proxy_quota_exceeded")
+	ErrCertQuota  = errors.New("cert_quota_exceeded")
+)
+
+// Usage is what a caller has consumed against its limits. Zero maximums
+// mean unlimited.
+type Usage struct {
+	Identity          string `json:"identity"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	Proxies           int    `json:"proxies"`
+	MaxProxies        int    `json:"max_proxies"`
+	CertsThisWeek     int    `json:"certs_this_week"`
+	MaxCertsPerWeek   int    `json:"max_certs_per_week"`
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Logs builds the root logger and named subsystem loggers whose levels can
+// be changed while running.
+type Logs struct {
+	mu         sync.RWMutex
+	root       zapcore.Level
+	subsystems map[string]zapcore.Level
+
+	core zapcore.Core
+}
+
+// New builds the loggers for cfg. Logs go to out unless cfg.File is set.
+func New(cfg config.Logging, out io.Writer) (*Logs, error) {
+	l := &Logs{subsystems: make(map[string]zapcore.Level)}
+
+	if err := l.SetLevel("", cfg.Level); err != nil {
+		return nil, err
+	}
+	for name, level := range cfg.Subsystems {
+		if err := l.SetLevel(name, level); err != nil {
+			return nil, fmt.Errorf("subsystem %s: %w", name, err)
+		}
+	}
+
+	if cfg.File != "" {
+		out = &lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxAge:     cfg.MaxAgeDays,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+		}
+	}
+
+	var encoder zapcore.Encoder
+	switch cfg.Format {
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+			TimeKey:     "time",
+			LevelKey:    "level",
+			NameKey:     "logger",
+			MessageKey:  "msg",
+			EncodeTime:  zapcore.TimeEncoderOfLayout("2006.01.02 15:04:05.000"),
+			EncodeLevel: zapcore.CapitalLevelEncoder,
+			EncodeName:  zapcore.FullNameEncoder,
+		})
+	case "json":
+		encoderCfg := zap.NewProductionEncoderConfig()
+		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	// the shared core accepts everything, each logger filters by its own level
+	l.core = zapcore.NewCore(encoder, zapcore.AddSync(out), zapcore.DebugLevel)
+	return l, nil
+}
+
+// Logger returns the logger for a subsystem; an empty name is the root logger.
+func (l *Logs) Logger(name string) *zap.Logger {
+	core := &levelCore{Core: l.core, level: subsystemLevel{logs: l, name: name}}
+
+	log := zap.New(core)
+	if name != "" {
+		log = log.Named(name)
+	}
+	return log
+}
+
+// SetLevel changes the level of a subsystem, or the root level when name is
+// empty. An empty level drops the subsystem override.
+func (l *Logs) SetLevel(name, level string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if name != "" && level == "" {
+		delete(l.subsystems, name)
+		return nil
+	}
+
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrUnknownLevel, level)
+	}
+
+	if name == "" {
+		l.root = parsed
+	} else {
+		l.subsystems[name] = parsed
+	}
+	return nil
+}
+
+func (l *Logs) Levels() Levels {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	levels := Levels{Level: l.root.String(), Subsystems: make(map[string]string)}
+	for name, level := range l.subsystems {
+		levels.Subsystems[name] = level.String()
+	}
+	return levels
+}
+
+func (l *Logs) level(name string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if level, ok := l.subsystems[name]; ok {
+		return level
+	}
+	return l.root
+}
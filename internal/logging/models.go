@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrUnknownLevel = errors.New("unknown log level")
+
+// Levels is the current root level and the per-subsystem overrides.
+type Levels struct {
+	Level      string            `json:"level"`
+	Subsystems map[string]string `json:"subsystems"`
+}
+
+// subsystemLevel resolves the level of one named logger on every call, so
+// changes through SetLevel apply to loggers that were already handed out.
+type subsystemLevel struct {
+	logs *Logs
+	name string
+}
+
+func (s subsystemLevel) Enabled(l zapcore.Level) bool {
+	return s.logs.level(s.name).Enabled(l)
+}
+
+// levelCore filters entries of a core by a level enabler that may be lower
+// than the wrapped core's own level.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(e.Level) {
+		return ce
+	}
+	return c.Core.Check(e, ce)
+}
@@ -0,0 +1,103 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"go.uber.org/zap"
+)
+
+var ErrProxyNotFound = errors.New("proxy_not_found")
+
+// Manager switches proxies between their normal config and the maintenance
+// page, keeping the stored state and the rendered nginx config in step.
+type Manager struct {
+	cfg   *config.Current
+	log   *zap.Logger
+	store *store.Store
+	mu    sync.Mutex
+}
+
+func New(cfg *config.Current, log *zap.Logger, st *store.Store) *Manager {
+	return &Manager{
+		cfg:   cfg,
+		log:   log,
+		store: st,
+	}
+}
+
+// Set turns maintenance mode for domain on or off and reports whether
+// anything changed. auto marks changes made by health checks; those never
+// override a mode set through the API.
+func (m *Manager) Set(ctx context.Context, domain string, on, auto bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.store.Get(domain)
+	if !ok {
+		return false, ErrProxyNotFound
+	}
+
+	if auto && (on && p.Maintenance || !on && !p.AutoMaintenance) {
+		return false, nil
+	}
+	if p.Maintenance == on && p.AutoMaintenance == (on && auto) {
+		return false, nil
+	}
+
+	p.Maintenance = on
+	p.AutoMaintenance = on && auto
+
+	if err := m.Apply(ctx, p); err != nil {
+		return false, err
+	}
+
+	err := m.store.Update(domain, func(sp *store.Proxy) {
+		sp.Maintenance = p.Maintenance
+		sp.AutoMaintenance = p.AutoMaintenance
+	})
+	return err == nil, err
+}
+
+// Apply renders the config matching p's maintenance state if the one on
+// disk differs.
+func (m *Manager) Apply(ctx context.Context, p store.Proxy) error {
+	fileName := p.Domain + ".conf"
+	if nginx.IsMaintenance(fileName) == p.Maintenance {
+		return nil
+	}
+
+	cfg := m.cfg.Get()
+	if p.Maintenance {
+		return nginx.SetMaintenance(ctx, p.Domain, p.CertDomain, cfg.MaintenanceCfgTemplate, cfg.Maintenance.Page, fileName)
+	}
+	return nginx.RestoreConfig(ctx, p.Domain, p.CertDomain, p.Target, cfg.NginxCfgTemplate, fileName)
+}
+
+// HandleHealth is a health.Prober hook that puts a proxy into maintenance
+// after FailThreshold failed checks and takes it out once the target is back.
+func (m *Manager) HandleHealth(domain string, st health.Status) {
+	var on bool
+	switch {
+	case st.Failures >= m.cfg.Get().Maintenance.FailThreshold:
+		on = true
+	case st.Healthy:
+		on = false
+	default:
+		return
+	}
+
+	changed, err := m.Set(context.Background(), domain, on, true)
+	if err != nil {
+		m.log.Error("failed to switch maintenance mode", zap.String("domain", domain), zap.Bool("maintenance", on), zap.Error(err))
+		return
+	}
+	if changed {
+		m.log.Info("maintenance mode switched by health check", zap.String("domain", domain), zap.Bool("maintenance", on))
+	}
+}
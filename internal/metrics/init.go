@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	APIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "npapi_http_requests_total",
+		Help: "API requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	APIDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "npapi_http_request_duration_seconds",
+		Help:    "API request duration by route and method.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 30, 60, 120},
+	}, []string{"route", "method"})
+
+	ProxyOps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "npapi_proxy_operations_total",
+		Help: "Proxy add/remove operations by outcome and the step that failed.",
+	}, []string{"op", "outcome", "step"})
+
+	CloudflareDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "npapi_cloudflare_request_duration_seconds",
+		Help:    "Cloudflare API request duration by method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "status"})
+
+	CertbotDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "npapi_certbot_duration_seconds",
+		Help:    "certbot invocation duration by command and outcome.",
+		Buckets: []float64{0.5, 1, 5, 10, 30, 60, 120, 300},
+	}, []string{"command", "outcome"})
+
+	NginxDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "npapi_nginx_command_duration_seconds",
+		Help:    "nginx config test and reload duration by command and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"command", "outcome"})
+)
+
+// Outcome turns an error into the "outcome" label value.
+func Outcome(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/gin-gonic/gin"
+)
+
+// Requests records count and duration of every API request.
+func Requests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		APIRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		APIDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ProxyOp counts the outcome of a proxy operation after the handler has run.
+func ProxyOp(op string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apierr.SetStep(c, "validate")
+		c.Next()
+
+		if c.Writer.Status() < http.StatusBadRequest {
+			ProxyOps.WithLabelValues(op, "success", "").Inc()
+			return
+		}
+		ProxyOps.WithLabelValues(op, "failure", apierr.Step(c)).Inc()
+	}
+}
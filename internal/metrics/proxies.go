@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	proxiesDesc = prometheus.NewDesc(
+		"npapi_proxies",
+		"Managed proxies by state.",
+		[]string{"state"}, nil,
+	)
+	certExpiryDesc = prometheus.NewDesc(
+		"npapi_cert_expiry_days",
+		"Days until the certificate used by managed proxies expires.",
+		[]string{"cert"}, nil,
+	)
+)
+
+// CertExpiry returns when the certificate with the given name expires. It is
+// passed in because certbot itself reports its metrics here.
+type CertExpiry func(name string) (time.Time, error)
+
+// proxyCollector reports proxy counts and certificate expiry from the store
+// and the certbot live directory at scrape time.
+type proxyCollector struct {
+	store      *store.Store
+	certExpiry CertExpiry
+}
+
+// RegisterProxies exposes proxy and certificate gauges for st.
+func RegisterProxies(st *store.Store, certExpiry CertExpiry) {
+	prometheus.MustRegister(&proxyCollector{store: st, certExpiry: certExpiry})
+}
+
+func (p *proxyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- proxiesDesc
+	ch <- certExpiryDesc
+}
+
+func (p *proxyCollector) Collect(ch chan<- prometheus.Metric) {
+	states := map[string]int{"active": 0, "disabled": 0, "maintenance": 0, "pending": 0}
+	certs := make(map[string]struct{})
+
+	for _, proxy := range p.store.List() {
+		switch {
+		case proxy.Pending:
+			states["pending"]++
+			continue
+		case proxy.Disabled:
+			states["disabled"]++
+		case proxy.Maintenance:
+			states["maintenance"]++
+		default:
+			states["active"]++
+		}
+		certs[proxy.CertDomain] = struct{}{}
+	}
+
+	for state, n := range states {
+		ch <- prometheus.MustNewConstMetric(proxiesDesc, prometheus.GaugeValue, float64(n), state)
+	}
+
+	for cert := range certs {
+		notAfter, err := p.certExpiry(cert)
+		if err != nil {
+			continue
+		}
+		days := time.Until(notAfter).Hours() / 24
+		ch <- prometheus.MustNewConstMetric(certExpiryDesc, prometheus.GaugeValue, days, cert)
+	}
+}
@@ -0,0 +1,34 @@
+package nginx
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+// CheckConfig makes sure the nginx binary is installed and the current
+// configuration passes "nginx -t".
+func CheckConfig() error {
+	if _, err := exec.LookPath("nginx"); err != nil {
+		return err
+	}
+
+	output, err := runNginx(context.Background(), "test", "-t")
+	if err != nil {
+		return fmt.Errorf("%w: %w, output: %s", ErrTestFailed, err, output)
+	}
+	return nil
+}
+
+// CheckDirs makes sure site configs can be written and linked, without
+// touching the directories.
+func CheckDirs() error {
+	for _, dir := range []string{sitesAvailable, sitesEnabled} {
+		if err := unix.Access(dir, unix.W_OK|unix.X_OK); err != nil {
+			return fmt.Errorf("%s: %w", dir, err)
+		}
+	}
+	return nil
+}
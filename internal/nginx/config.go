@@ -1,47 +1,147 @@
 package nginx
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
-func AddConfig(domain, cert, target, tmplStr, fileName string) error {
+func AddConfig(ctx context.Context, domain, cert, target, tmplStr, fileName string) error {
+	cfg := tmplConfig{
+		Domain: domain,
+		Cert:   cert,
+		Target: target,
+	}
+
+	if err := writeConfig(filepath.Join(sitesAvailable, fileName), managedMarker, tmplStr, cfg); err != nil {
+		return err
+	}
+
+	return EnableSite(ctx, fileName)
+}
+
+func RemoveConfig(ctx context.Context, fileName string) error {
+	paths := []string{
+		"/etc/nginx/sites-enabled/" + fileName,
+		"/etc/nginx/sites-available/" + fileName,
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	return reloadNginx(ctx)
+}
+
+// HasConfig reports whether a site config with fileName exists.
+func HasConfig(fileName string) bool {
+	_, err := os.Stat(filepath.Join(sitesAvailable, fileName))
+	return err == nil
+}
+
+// ListManagedConfigs returns file names of site configs written by this package.
+func ListManagedConfigs() ([]string, error) {
+	entries, err := os.ReadDir(sitesAvailable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", sitesAvailable, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
+			continue
+		}
+		if strings.HasPrefix(readMarker(entry.Name()), managedMarker) {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// CheckTemplate parses tmplStr and renders it with sample values, so that
+// template errors show up when the config is loaded rather than when the
+// first proxy is added.
+func CheckTemplate(tmplStr string) error {
+	tmpl, err := template.New("nginx").Parse(tmplStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	cfg := tmplConfig{
+		Domain:   "sub.example.com",
+		Cert:     "example.com",
+		Target:   "127.0.0.1:8080",
+		PageDir:  "/var/www/maintenance",
+		PageName: "index.html",
+	}
+	if err := tmpl.Execute(io.Discard, cfg); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return nil
+}
+
+func writeConfig(path, marker, tmplStr string, cfg tmplConfig) error {
 	tmpl, err := template.New("nginx").Parse(tmplStr)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	file, err := os.Create("/etc/nginx/sites-available/" + fileName)
+	file, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
-	cfg := tmplConfig{
-		Domain: domain,
-		Cert:   cert,
-		Target: target,
+	if _, err := file.WriteString(marker + "\n"); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
 	}
 
 	if err := tmpl.Execute(file, cfg); err != nil {
 		return fmt.Errorf("failed to generate config: %w", err)
 	}
 
-	return activateSite(fileName)
+	return nil
 }
 
-func RemoveConfig(fileName string) error {
-	paths := []string{
-		"/etc/nginx/sites-enabled/" + fileName,
-		"/etc/nginx/sites-available/" + fileName,
+// rewriteConfig replaces an existing site config and reloads nginx. If nginx
+// rejects the new config, the previous one is put back.
+func rewriteConfig(ctx context.Context, fileName, marker, tmplStr string, cfg tmplConfig) error {
+	path := filepath.Join(sitesAvailable, fileName)
+
+	prev, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read current config: %w", err)
 	}
 
-	for _, path := range paths {
-		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to remove %s: %w", path, err)
+	if err := writeConfig(path, marker, tmplStr, cfg); err != nil {
+		return err
+	}
+
+	if err := reloadNginx(ctx); err != nil {
+		if rerr := os.WriteFile(path, prev, 0o644); rerr != nil {
+			return fmt.Errorf("%w (failed to restore previous config: %v)", err, rerr)
 		}
+		return err
+	}
+
+	return nil
+}
+
+func readMarker(fileName string) string {
+	file, err := os.Open(filepath.Join(sitesAvailable, fileName))
+	if err != nil {
+		return ""
 	}
+	defer file.Close()
 
-	return reloadNginx()
+	line, _ := bufio.NewReader(file).ReadString('\n')
+	return strings.TrimSpace(line)
 }
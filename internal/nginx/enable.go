@@ -1,12 +1,14 @@
 package nginx
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 )
 
-func activateSite(fileName string) error {
+// EnableSite links an existing site config into sites-enabled and reloads nginx.
+func EnableSite(ctx context.Context, fileName string) error {
 	src := filepath.Join(sitesAvailable, fileName)
 	dst := filepath.Join(sitesEnabled, fileName)
 
@@ -18,8 +20,35 @@ func activateSite(fileName string) error {
 		if err := os.Symlink(src, dst); err != nil {
 			return fmt.Errorf("failed to create symlink: %v", err)
 		}
-	} else {
-		fmt.Println("symlink is already exists")
 	}
-	return reloadNginx()
+	return reloadNginx(ctx)
+}
+
+// DisableSite unlinks the site from sites-enabled and reloads nginx. The
+// config itself stays in sites-available. If the reload fails the link is
+// put back.
+func DisableSite(ctx context.Context, fileName string) error {
+	src := filepath.Join(sitesAvailable, fileName)
+	dst := filepath.Join(sitesEnabled, fileName)
+
+	if err := os.Remove(dst); err != nil {
+		if os.IsNotExist(err) {
+			return reloadNginx(ctx)
+		}
+		return fmt.Errorf("failed to remove symlink: %v", err)
+	}
+
+	if err := reloadNginx(ctx); err != nil {
+		if rerr := os.Symlink(src, dst); rerr != nil {
+			return fmt.Errorf("%w (failed to restore symlink: %v)", err, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// IsEnabled reports whether the site is linked into sites-enabled.
+func IsEnabled(fileName string) bool {
+	_, err := os.Lstat(filepath.Join(sitesEnabled, fileName))
+	return err == nil
 }
@@ -0,0 +1,70 @@
+package nginx
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// defaultMaintenanceTemplate answers every request with 503, serving the
+// maintenance page as the error body when one is configured.
+const defaultMaintenanceTemplate = `server {
+    listen 80;
+    server_name {{.Domain}};
+    return 301 https://$host$request_uri;
+}
+
+server {
+    listen 443 ssl http2;
+    server_name {{.Domain}};
+
+    ssl_certificate /etc/letsencrypt/live/{{.Cert}}/fullchain.pem;
+    ssl_certificate_key /etc/letsencrypt/live/{{.Cert}}/privkey.pem;
+    include /etc/letsencrypt/options-ssl-nginx.conf;
+{{if .PageName}}
+    root {{.PageDir}};
+    error_page 503 /{{.PageName}};
+
+    location = /{{.PageName}} {
+        internal;
+    }
+{{end}}
+    location / {
+        return 503;
+    }
+}
+`
+
+// SetMaintenance swaps the site config to the maintenance template. An empty
+// tmplStr selects the built-in template, an empty page makes it a bare 503.
+func SetMaintenance(ctx context.Context, domain, cert, tmplStr, page, fileName string) error {
+	if tmplStr == "" {
+		tmplStr = defaultMaintenanceTemplate
+	}
+
+	cfg := tmplConfig{
+		Domain: domain,
+		Cert:   cert,
+	}
+	if page != "" {
+		cfg.PageDir = filepath.Dir(page)
+		cfg.PageName = filepath.Base(page)
+	}
+
+	return rewriteConfig(ctx, fileName, maintenanceMarker, tmplStr, cfg)
+}
+
+// RestoreConfig renders the normal proxy config over a maintenance one.
+func RestoreConfig(ctx context.Context, domain, cert, target, tmplStr, fileName string) error {
+	cfg := tmplConfig{
+		Domain: domain,
+		Cert:   cert,
+		Target: target,
+	}
+
+	return rewriteConfig(ctx, fileName, managedMarker, tmplStr, cfg)
+}
+
+// IsMaintenance reports whether the site config is currently in maintenance mode.
+func IsMaintenance(fileName string) bool {
+	return readMarker(fileName) == maintenanceMarker
+}
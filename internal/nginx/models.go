@@ -1,12 +1,29 @@
 package nginx
 
+import "errors"
+
+var (
+	ErrTestFailed   = errors.New("nginx config test failed")
+	ErrReloadFailed = errors.New("nginx reload failed")
+)
+
 var (
 	sitesAvailable = "/etc/nginx/sites-available/"
 	sitesEnabled   = "/etc/nginx/sites-enabled/"
 )
 
+// The first line of every config written by this package tells which
+// template it was rendered from.
+const (
+	managedMarker     = "# managed by nginx-proxy-api"
+	maintenanceMarker = managedMarker + ": maintenance"
+)
+
 type tmplConfig struct {
 	Domain string
 	Cert   string
 	Target string
+
+	PageDir  string
+	PageName string
 }
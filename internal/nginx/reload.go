@@ -1,22 +1,49 @@
 package nginx
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
-func reloadNginx() error {
-	cmd := exec.Command("nginx", "-t")
-	output, err := cmd.CombinedOutput()
+// tracer is used instead of tracing.Start, which would import config and
+// with it this package.
+var tracer = otel.Tracer("github.com/d1manpro/nginx-proxy-api")
+
+// reloadNginx tests and reloads the configuration. ctx only parents the
+// spans: a reload is never cut short by a canceled request.
+func reloadNginx(ctx context.Context) error {
+	output, err := runNginx(ctx, "test", "-t")
 	if err != nil {
-		return fmt.Errorf("nginx config test failed: %w, output: %s", err, output)
+		return fmt.Errorf("%w: %w, output: %s", ErrTestFailed, err, output)
 	}
 
-	cmd = exec.Command("nginx", "-s", "reload")
-	output, err = cmd.CombinedOutput()
+	output, err = runNginx(ctx, "reload", "-s", "reload")
 	if err != nil {
-		return fmt.Errorf("nginx reload failed: %w, output: %s", err, output)
+		return fmt.Errorf("%w: %w, output: %s", ErrReloadFailed, err, output)
 	}
 
 	return nil
 }
+
+func runNginx(ctx context.Context, command string, args ...string) ([]byte, error) {
+	_, span := tracer.Start(ctx, "nginx "+command)
+	span.SetAttributes(attribute.String("process.command_line", "nginx "+strings.Join(args, " ")))
+	defer span.End()
+
+	start := time.Now()
+	output, err := exec.Command("nginx", args...).CombinedOutput()
+	metrics.NginxDuration.WithLabelValues(command, metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return output, err
+}
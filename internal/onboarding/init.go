@@ -0,0 +1,157 @@
+package onboarding
+
+import (
+	"context"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
+	"github.com/d1manpro/nginx-proxy-api/internal/nginx"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/d1manpro/nginx-proxy-api/internal/tracing"
+	"go.uber.org/zap"
+)
+
+// Worker finishes the setup of pending custom-domain proxies once their DNS
+// points at this node, and drops the ones that stay pending for too long.
+type Worker struct {
+	cfg    *config.Current
+	log    *zap.Logger
+	store  *store.Store
+	dns    *dnscheck.Checker
+	limits *limits.Limiter
+	tokens *auth.Tokens
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func New(cfg *config.Current, log *zap.Logger, st *store.Store, dc *dnscheck.Checker, lim *limits.Limiter, tokens *auth.Tokens) *Worker {
+	return &Worker{
+		cfg:    cfg,
+		log:    log,
+		store:  st,
+		dns:    dc,
+		limits: lim,
+		tokens: tokens,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (w *Worker) Start() {
+	interval := w.cfg.Get().Onboarding.CheckInterval
+	w.log.Info("Starting onboarding worker", zap.Duration("interval", interval))
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			w.run()
+			ticker.Reset(w.cfg.Get().Onboarding.CheckInterval)
+
+			select {
+			case <-ticker.C:
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (w *Worker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+func (w *Worker) run() {
+	ttl := w.cfg.Get().Onboarding.PendingTTL
+
+	for _, p := range w.store.List() {
+		if !p.Pending {
+			continue
+		}
+
+		if time.Since(p.CreatedAt) > ttl {
+			if err := w.store.Delete(p.Domain); err != nil {
+				w.log.Error("failed to remove expired pending proxy", zap.String("domain", p.Domain), zap.Error(err))
+				continue
+			}
+			w.log.Info("pending proxy expired", zap.String("domain", p.Domain))
+			continue
+		}
+
+		if res := w.dns.Check(context.Background(), p.Domain); !res.OK {
+			continue
+		}
+
+		// every node runs the worker; the claim makes sure only one of
+		// them issues the certificate and charges the quota
+		claimed, err := w.store.Claim(p.Domain)
+		if err != nil {
+			w.log.Error("failed to claim pending proxy", zap.String("domain", p.Domain), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := w.activate(p); err != nil {
+			w.log.Error("failed to activate pending proxy", zap.String("domain", p.Domain), zap.Error(err))
+			if err := w.store.Update(p.Domain, func(sp *store.Proxy) {
+				sp.Pending, sp.Provisioning = true, false
+			}); err != nil {
+				w.log.Error("failed to release pending proxy", zap.String("domain", p.Domain), zap.Error(err))
+			}
+			continue
+		}
+		w.log.Info("pending proxy activated", zap.String("domain", p.Domain))
+	}
+}
+
+func (w *Worker) activate(p store.Proxy) error {
+	ctx, span := tracing.Start(context.Background(), "onboarding.activate")
+	defer span.End()
+
+	cfg := w.cfg.Get()
+
+	// a certificate left over from an earlier attempt that failed later on
+	// was already charged, so it is reused instead of issued again
+	exists, err := certbot.IsCertExists(ctx, p.Domain)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		cancelCert, err := w.limits.ReserveCert(w.owner(p))
+		if err != nil {
+			return err
+		}
+		if err := certbot.GetCert(ctx, p.Domain, cfg.Email); err != nil {
+			cancelCert()
+			return err
+		}
+	}
+
+	if err := nginx.AddConfig(ctx, p.Domain, p.CertDomain, p.Target, cfg.NginxCfgTemplate, p.Domain+".conf"); err != nil {
+		return err
+	}
+
+	return w.store.Update(p.Domain, func(sp *store.Proxy) {
+		sp.Provisioning = false
+	})
+}
+
+// owner is the identity the proxy was added by, with its per-token limits.
+// Identities that are not in the token store, like certificate or JWT
+// callers and revoked tokens, get the global limits.
+func (w *Worker) owner(p store.Proxy) auth.Identity {
+	if identity, ok := w.tokens.Lookup(p.CreatedBy); ok {
+		return identity
+	}
+	return auth.Identity{Name: p.CreatedBy}
+}
@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+
+	pathParam = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+// Spec returns the OpenAPI 3 document of the API. Schemas are derived from
+// the handler types, so they change together with the handlers.
+func Spec() []byte {
+	specOnce.Do(func() {
+		specJSON, _ = json.MarshalIndent(build(), "", "  ")
+	})
+	return specJSON
+}
+
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", Spec())
+	}
+}
+
+func build() map[string]any {
+	s := &schemas{components: make(map[string]any)}
+	errorResp := map[string]any{
+		"description": "Error",
+		"content":     jsonContent(s.ref(errorBody)),
+	}
+
+	paths := make(map[string]any)
+	for _, op := range operations {
+		item, ok := paths[op.path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[op.path] = item
+		}
+
+		var params []any
+		for _, m := range pathParam.FindAllStringSubmatch(op.path, -1) {
+			params = append(params, map[string]any{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, q := range op.query {
+			params = append(params, map[string]any{
+				"name": q.name, "in": "query", "description": q.description,
+				"schema": map[string]any{"type": q.typ},
+			})
+		}
+
+		responses := map[string]any{"default": errorResp}
+		for _, r := range op.responses {
+			resp := map[string]any{"description": r.description}
+			switch r.body.(type) {
+			case nil:
+			case textBody:
+				resp["content"] = map[string]any{"text/plain": map[string]any{"schema": map[string]any{"type": "string"}}}
+			default:
+				resp["content"] = jsonContent(s.ref(r.body))
+			}
+			responses[strconv.Itoa(r.status)] = resp
+		}
+
+		operation := map[string]any{
+			"operationId": op.id,
+			"summary":     op.summary,
+			"responses":   responses,
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(s.ref(op.request)),
+			}
+		}
+		if op.public {
+			operation["security"] = []any{}
+		} else if op.scope != "" {
+			operation["description"] = "Requires the `" + op.scope + "` scope."
+		}
+
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "nginx-proxy-api",
+			"version": "1.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": s.components,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{map[string]any{"bearer": []any{}}},
+	}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
@@ -0,0 +1,181 @@
+package openapi
+
+import (
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/d1manpro/nginx-proxy-api/internal/handler"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
+	"github.com/d1manpro/nginx-proxy-api/internal/logging"
+)
+
+var errorBody = apierr.ErrorResp{}
+
+type operation struct {
+	method  string
+	path    string
+	id      string
+	summary string
+	scope   string
+	public  bool
+
+	query     []query
+	request   any
+	responses []response
+}
+
+type query struct {
+	name        string
+	typ         string
+	description string
+}
+
+type response struct {
+	status      int
+	description string
+	body        any
+}
+
+// textBody marks a plain text response.
+type textBody struct{}
+
+// operations lists every route registered in router.Server. Keep it in the
+// same order as the router; a router test fails when the two disagree.
+var operations = []operation{
+	{
+		method: "GET", path: "/healthz", id: "healthz", public: true,
+		summary:   "Liveness probe",
+		responses: []response{{200, "Process is alive", handler.StatusResp{}}},
+	},
+	{
+		method: "GET", path: "/readyz", id: "readyz", public: true,
+		summary: "Readiness probe",
+		responses: []response{
+			{200, "All checks passed", handler.ReadyResp{}},
+			{503, "At least one check failed", handler.ReadyResp{}},
+		},
+	},
+	{
+		method: "GET", path: "/openapi.json", id: "openapi", public: true,
+		summary:   "This document",
+		responses: []response{{200, "OpenAPI document", map[string]any{}}},
+	},
+	{
+		method: "GET", path: "/proxy", id: "listProxies", scope: auth.ScopeProxyRead,
+		summary:   "List proxies with target health",
+		responses: []response{{200, "Proxies visible to the caller", []handler.ProxyResp{}}},
+	},
+	{
+		method: "GET", path: "/proxy/{domain}/health", id: "proxyHealth", scope: auth.ScopeProxyRead,
+		summary:   "Target health of a proxy",
+		responses: []response{{200, "Last probe result", health.Status{}}},
+	},
+	{
+		method: "GET", path: "/domains/{domain}/check", id: "checkDomain", scope: auth.ScopeProxyRead,
+		summary:   "Check DNS of a custom domain",
+		responses: []response{{200, "DNS report", dnscheck.Result{}}},
+	},
+	{
+		method: "POST", path: "/proxy", id: "addProxy", scope: auth.ScopeProxyWrite,
+		summary: "Add a proxy",
+		request: handler.AddDomainReq{},
+		responses: []response{
+			{201, "Proxy created", handler.StatusResp{}},
+			{202, "Custom domain accepted as pending", handler.PendingResp{}},
+		},
+	},
+	{
+		method: "DELETE", path: "/proxy", id: "removeProxy", scope: auth.ScopeProxyWrite,
+		summary:   "Remove a proxy",
+		request:   handler.RemoveDomainReq{},
+		responses: []response{{204, "Proxy removed", nil}},
+	},
+	{
+		method: "POST", path: "/proxy/{domain}/enable", id: "enableProxy", scope: auth.ScopeProxyWrite,
+		summary:   "Enable a disabled proxy",
+		responses: []response{{200, "Proxy enabled", handler.EnabledResp{}}},
+	},
+	{
+		method: "POST", path: "/proxy/{domain}/disable", id: "disableProxy", scope: auth.ScopeProxyWrite,
+		summary:   "Disable a proxy, keeping config, DNS and certificate",
+		responses: []response{{200, "Proxy disabled", handler.EnabledResp{}}},
+	},
+	{
+		method: "POST", path: "/proxy/{domain}/maintenance", id: "setMaintenance", scope: auth.ScopeProxyWrite,
+		summary:   "Turn maintenance mode on or off",
+		request:   handler.MaintenanceReq{},
+		responses: []response{{200, "Maintenance mode switched", handler.MaintenanceResp{}}},
+	},
+	{
+		method: "GET", path: "/certs", id: "listCerts", scope: auth.ScopeCertManage,
+		summary:   "List certificates",
+		responses: []response{{200, "Certificates in the certbot live directory", []certbot.Cert{}}},
+	},
+	{
+		method: "POST", path: "/certs/{name}/renew", id: "renewCert", scope: auth.ScopeCertManage,
+		summary: "Renew a certificate",
+		query: []query{
+			{"force", "boolean", "renew even if the certificate is not due"},
+		},
+		responses: []response{{200, "Certificate renewed", handler.StatusResp{}}},
+	},
+	{
+		method: "GET", path: "/tokens", id: "listTokens", scope: auth.ScopeAdmin,
+		summary:   "List API tokens",
+		responses: []response{{200, "Tokens without secrets", []handler.TokenResp{}}},
+	},
+	{
+		method: "POST", path: "/tokens", id: "createToken", scope: auth.ScopeAdmin,
+		summary:   "Create an API token",
+		request:   handler.CreateTokenReq{},
+		responses: []response{{201, "Token created, the secret is only shown once", handler.TokenResp{}}},
+	},
+	{
+		method: "DELETE", path: "/tokens/{name}", id: "revokeToken", scope: auth.ScopeAdmin,
+		summary:   "Revoke an API token",
+		responses: []response{{204, "Token revoked", nil}},
+	},
+	{
+		method: "GET", path: "/usage", id: "usage",
+		summary: "Rate limit and quota usage of the caller",
+		query: []query{
+			{"token", "string", "another token's usage, admin only"},
+		},
+		responses: []response{{200, "Usage", limits.Usage{}}},
+	},
+	{
+		method: "GET", path: "/audit", id: "listAudit", scope: auth.ScopeAdmin,
+		summary: "Read the audit log",
+		query: []query{
+			{"from", "string", "RFC 3339 time"},
+			{"to", "string", "RFC 3339 time"},
+			{"domain", "string", "only entries for this domain"},
+			{"limit", "integer", "maximum number of entries, default 100"},
+		},
+		responses: []response{{200, "Audit entries", []audit.Entry{}}},
+	},
+	{
+		method: "GET", path: "/audit/verify", id: "verifyAudit", scope: auth.ScopeAdmin,
+		summary:   "Verify the audit hash chain",
+		responses: []response{{200, "Verification result", handler.AuditVerifyResp{}}},
+	},
+	{
+		method: "GET", path: "/log/level", id: "getLogLevel", scope: auth.ScopeAdmin,
+		summary:   "Current log levels",
+		responses: []response{{200, "Log levels", logging.Levels{}}},
+	},
+	{
+		method: "PUT", path: "/log/level", id: "setLogLevel", scope: auth.ScopeAdmin,
+		summary:   "Change the root or a subsystem log level",
+		request:   handler.LogLevelReq{},
+		responses: []response{{200, "Log levels", logging.Levels{}}},
+	},
+	{
+		method: "GET", path: "/metrics", id: "metrics", scope: auth.ScopeProxyRead,
+		summary:   "Prometheus metrics",
+		responses: []response{{200, "Metrics in the Prometheus text format", textBody{}}},
+	},
+}
@@ -0,0 +1,62 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const Header = "X-Request-ID"
+
+const ginKey = "npa_request_id"
+
+type ctxKey struct{}
+
+// accepted limits the IDs taken from clients to something safe to log.
+var accepted = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Middleware takes the request ID from X-Request-ID or generates one, and
+// echoes it in the response. The ID is also put into the request context for
+// outgoing calls.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !accepted.MatchString(id) {
+			id = generate()
+		}
+
+		c.Set(ginKey, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxKey{}, id))
+		c.Header(Header, id)
+
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request.id", id))
+
+		c.Next()
+	}
+}
+
+func Get(c *gin.Context) string {
+	return c.GetString(ginKey)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Field is the request ID as a log field.
+func Field(c *gin.Context) zap.Field {
+	return zap.String("request_id", Get(c))
+}
+
+func generate() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -2,96 +2,283 @@ package router
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
-	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/d1manpro/nginx-proxy-api/internal/apierr"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/certbot"
 	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
 	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
 	"github.com/d1manpro/nginx-proxy-api/internal/handler"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
+	"github.com/d1manpro/nginx-proxy-api/internal/logging"
+	"github.com/d1manpro/nginx-proxy-api/internal/maintenance"
+	"github.com/d1manpro/nginx-proxy-api/internal/metrics"
+	"github.com/d1manpro/nginx-proxy-api/internal/openapi"
+	"github.com/d1manpro/nginx-proxy-api/internal/requestid"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/d1manpro/nginx-proxy-api/internal/tracing"
+	"github.com/d1manpro/nginx-proxy-api/signature"
 	"github.com/gin-contrib/cors"
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type Server struct {
 	router *gin.Engine
 	srv    *http.Server
 	cfAPI  *cloudflare.CfAPI
-	cfg    *config.Config
+	store  *store.Store
+	health *health.Prober
+	maint  *maintenance.Manager
+	tokens *auth.Tokens
+	audit  *audit.Log
+	limits *limits.Limiter
+	dns    *dnscheck.Checker
+	logs   *logging.Logs
+	cfg    *config.Current
 	log    *zap.Logger
+
+	// started is the config the server was started with, for telling which
+	// reloaded keys need a restart
+	started *config.Config
+
+	ipFilter *auth.IPFilter
+	jwt      atomic.Pointer[auth.JWTVerifier]
+	cors     atomic.Pointer[gin.HandlerFunc]
 }
 
-func NewServer(cfg *config.Config, log *zap.Logger, cfAPI *cloudflare.CfAPI) *Server {
+func NewServer(current *config.Current, log *zap.Logger, logs *logging.Logs, cfAPI *cloudflare.CfAPI, st *store.Store, hc *health.Prober, mm *maintenance.Manager, tokens *auth.Tokens, ipFilter *auth.IPFilter, jwtVerifier *auth.JWTVerifier, al *audit.Log, lim *limits.Limiter, dc *dnscheck.Checker) *Server {
+	cfg := current.Get()
+	s := &Server{
+		cfg:      current,
+		cfAPI:    cfAPI,
+		store:    st,
+		health:   hc,
+		maint:    mm,
+		tokens:   tokens,
+		audit:    al,
+		limits:   lim,
+		dns:      dc,
+		logs:     logs,
+		log:      log,
+		ipFilter: ipFilter,
+		started:  cfg,
+	}
+	s.jwt.Store(jwtVerifier)
+	s.cors.Store(newCORS(cfg.Server))
+
 	if !cfg.DebugMode {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	r := gin.New()
 
-	r.SetTrustedProxies([]string{cfg.Server.Host})
+	// client IPs are taken from X-Forwarded-For only when the request comes
+	// from one of the trusted proxies
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Error("invalid trusted_proxies, trusting none", zap.Error(err))
+		r.SetTrustedProxies(nil)
+	}
+
+	r.Use(ginzap.GinzapWithConfig(log, &ginzap.Config{
+		TimeFormat: time.RFC3339,
+		UTC:        true,
+		Context: func(c *gin.Context) []zapcore.Field {
+			return []zapcore.Field{requestid.Field(c)}
+		},
+	}))
+	r.Use(ginzap.CustomRecoveryWithZap(log, true, func(c *gin.Context, err any) {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.CodeInternal, "internal error")
+	}))
+	r.Use(metrics.Requests())
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	r.Use(requestid.Middleware())
+	r.Use(tracing.Steps())
+	metrics.RegisterProxies(st, func(name string) (time.Time, error) {
+		cert, err := certbot.ReadCert(name)
+		return cert.NotAfter, err
+	})
 
-	r.Use(ginzap.Ginzap(log, time.RFC3339, true))
-	r.Use(ginzap.RecoveryWithZap(log, true))
+	// probes are registered ahead of the IP filter, so load balancers and
+	// orchestrators outside the allowlist can reach them
+	r.GET("/healthz", handler.Healthz())
+	r.GET("/readyz", handler.Readyz(log, cfAPI))
 
 	r.Use(func(c *gin.Context) {
 		clientIP := c.ClientIP()
-		if !slices.Contains(cfg.Access.AllowedIPs, clientIP) {
-			c.AbortWithStatus(http.StatusForbidden)
+		if !ipFilter.Allowed(clientIP) {
+			apierr.Abort(c, http.StatusForbidden, apierr.CodeIPDenied, "ip address not allowed")
 			return
 		}
 		c.Next()
 	})
 
+	// the API spec is registered ahead of the auth middleware and needs no
+	// token
+	r.GET("/openapi.json", openapi.Handler())
+
+	verifier := auth.NewVerifier(tokens, cfg.Access.SignatureMaxSkew)
+	lockout := auth.NewLockout(cfg.Access.Lockout.MaxFailures, cfg.Access.Lockout.Window, cfg.Access.Lockout.BanDuration)
+
 	r.Use(func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatus(http.StatusUnauthorized)
+		clientIP := c.ClientIP()
+		if left := lockout.Banned(clientIP); left > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(left.Seconds())+1))
+			apierr.Abort(c, http.StatusTooManyRequests, apierr.CodeLockedOut, "too many failed authentication attempts")
 			return
 		}
 
-		token := strings.TrimPrefix(authHeader, "Bearer ")
-		if token != cfg.Access.Token {
-			c.AbortWithStatus(http.StatusForbidden)
+		if identity, ok := certIdentity(c.Request.TLS, s.cfg.Get().Server.TLS.ClientIdentities); ok {
+			auth.SetIdentity(c, identity)
+			c.Next()
 			return
 		}
 
+		var identity auth.Identity
+		var err error
+
+		authHeader := c.GetHeader("Authorization")
+		switch {
+		case strings.HasPrefix(authHeader, "Bearer "):
+			token := strings.TrimPrefix(authHeader, "Bearer ")
+			if jwtVerifier := s.jwt.Load(); jwtVerifier != nil && auth.LooksLikeJWT(token) {
+				identity, err = jwtVerifier.Verify(token)
+			} else {
+				identity, err = tokens.Authenticate(token)
+			}
+		case strings.HasPrefix(authHeader, signature.Scheme+" "):
+			identity, err = verifier.Verify(c.Request)
+		default:
+			audit.Reject(s.audit, log, c, audit.OpAuthFailure, http.StatusUnauthorized, "missing credentials")
+			apierr.Abort(c, http.StatusUnauthorized, apierr.CodeUnauthorized, "missing credentials")
+			return
+		}
+		if err != nil {
+			banned := lockout.Fail(clientIP)
+			log.Warn("authentication failed",
+				requestid.Field(c),
+				zap.String("ip", clientIP),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Bool("banned", banned),
+				zap.Error(err),
+			)
+			audit.Reject(s.audit, log, c, audit.OpAuthFailure, http.StatusForbidden, err.Error())
+			if banned {
+				audit.Reject(s.audit, log, c, audit.OpAuthBan, http.StatusForbidden, "too many failed authentication attempts")
+			}
+			apierr.Abort(c, http.StatusForbidden, apierr.CodeInvalidCredentials, "invalid credentials")
+			return
+		}
+		lockout.Reset(clientIP)
+
+		auth.SetIdentity(c, identity)
 		c.Next()
 	})
 
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     cfg.Server.Origins,
-		AllowMethods:     []string{"GET", "POST", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		AllowCredentials: false,
-	}))
+	r.Use(func(c *gin.Context) {
+		if ok, wait := lim.Allow(auth.IdentityFrom(c)); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			apierr.Abort(c, http.StatusTooManyRequests, apierr.CodeRateLimited, "rate limit exceeded")
+			return
+		}
+		c.Next()
+	})
 
-	return &Server{
-		router: r,
-		cfg:    cfg,
-		cfAPI:  cfAPI,
-		log:    log,
-	}
+	// the CORS handler is rebuilt when the allowed origins are reloaded
+	r.Use(func(c *gin.Context) {
+		(*s.cors.Load())(c)
+	})
+
+	s.router = r
+	s.routes()
+
+	return s
 }
 
-func (s *Server) Start() {
-	s.router.GET("/test")
-	s.router.POST("/proxy", handler.AddProxy(s.cfg, s.log, s.cfAPI))
-	s.router.DELETE("/proxy", handler.RemoveProxy(s.cfg, s.log, s.cfAPI))
+// routes registers the authenticated API. Probes and the API spec are
+// registered in NewServer ahead of the auth middleware.
+func (s *Server) routes() {
+	read := requireScope(auth.ScopeProxyRead)
+	write := requireScope(auth.ScopeProxyWrite)
+	certs := requireScope(auth.ScopeCertManage)
+	admin := requireScope(auth.ScopeAdmin)
+
+	s.router.GET("/proxy", read, handler.ListProxies(s.store, s.health))
+	s.router.GET("/proxy/:domain/health", read, handler.ProxyHealth(s.store, s.health))
+	s.router.GET("/domains/:domain/check", read, handler.CheckDomain(s.dns))
+	s.router.POST("/proxy", write, s.record("proxy.add"), metrics.ProxyOp("add"), handler.AddProxy(s.cfg, s.log, s.cfAPI, s.store, s.health, s.limits, s.dns))
+	s.router.DELETE("/proxy", write, s.record("proxy.remove"), metrics.ProxyOp("remove"), handler.RemoveProxy(s.cfg, s.log, s.cfAPI, s.store))
+	s.router.POST("/proxy/:domain/enable", write, s.record("proxy.enable"), handler.ToggleProxy(s.log, s.store, true))
+	s.router.POST("/proxy/:domain/disable", write, s.record("proxy.disable"), handler.ToggleProxy(s.log, s.store, false))
+	s.router.POST("/proxy/:domain/maintenance", write, s.record("proxy.maintenance"), handler.SetMaintenance(s.log, s.store, s.maint))
+
+	s.router.GET("/certs", certs, handler.ListCerts(s.log))
+	s.router.POST("/certs/:name/renew", certs, s.record("cert.renew"), handler.RenewCert(s.log))
 
-	port := ":" + s.cfg.Server.Port
+	s.router.GET("/tokens", admin, handler.ListTokens(s.tokens))
+	s.router.POST("/tokens", admin, s.record("token.create"), handler.CreateToken(s.log, s.tokens))
+	s.router.DELETE("/tokens/:name", admin, s.record("token.revoke"), handler.RevokeToken(s.log, s.tokens))
+
+	s.router.GET("/usage", handler.Usage(s.tokens, s.limits))
+
+	s.router.GET("/audit", admin, handler.ListAudit(s.log, s.audit))
+	s.router.GET("/audit/verify", admin, handler.VerifyAudit(s.log, s.audit))
+
+	s.router.GET("/log/level", admin, handler.GetLogLevel(s.logs))
+	s.router.PUT("/log/level", admin, s.record("log.level"), handler.SetLogLevel(s.log, s.logs))
+
+	s.router.GET("/metrics", read, gin.WrapH(promhttp.Handler()))
+}
+
+// Handler is the API without a listener, for serving requests in-process.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+func (s *Server) Start() {
+	cfg := s.cfg.Get()
+	port := ":" + cfg.Server.Port
 
 	s.srv = &http.Server{
 		Addr:    port,
 		Handler: s.router,
 	}
 
-	s.log.Info("Starting server", zap.String("port", port))
+	tlsCfg := cfg.Server.TLS
+	if tlsCfg.CertFile != "" {
+		reloader, err := newCertReloader(tlsCfg, s.log)
+		if err != nil {
+			s.log.Fatal("failed to setup TLS", zap.Error(err))
+		}
+		s.srv.TLSConfig = &tls.Config{
+			GetConfigForClient: reloader.GetConfigForClient,
+		}
+	}
+
+	s.log.Info("Starting server", zap.String("port", port), zap.Bool("tls", s.srv.TLSConfig != nil))
 
 	go func() {
-		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.srv.TLSConfig != nil {
+			err = s.srv.ListenAndServeTLS("", "")
+		} else {
+			err = s.srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			s.log.Fatal("server failed", zap.Error(err))
 		}
 	}()
@@ -109,3 +296,28 @@ func (s *Server) Stop() {
 		s.log.Error("server shutdown error", zap.Error(err))
 	}
 }
+
+func (s *Server) record(op string) gin.HandlerFunc {
+	return audit.Record(s.audit, s.log, op)
+}
+
+func newCORS(cfg config.ServerConfig) *gin.HandlerFunc {
+	h := cors.New(cors.Config{
+		AllowOrigins:     cfg.Origins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", signature.HeaderTimestamp, signature.HeaderNonce, requestid.Header},
+		ExposeHeaders:    []string{requestid.Header},
+		AllowCredentials: false,
+	})
+	return &h
+}
+
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.IdentityFrom(c).HasScope(scope) {
+			apierr.Abort(c, http.StatusForbidden, apierr.CodeMissingScope, "missing scope "+scope)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package router
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/openapi"
+	"go.uber.org/zap"
+)
+
+var specParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// TestRoutesMatchSpec fails when a route is added to or removed from the
+// router without updating the hand-kept operation list in openapi.
+func TestRoutesMatchSpec(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: "8080", Origins: []string{"*"}},
+	}
+	s := NewServer(config.NewCurrent(cfg), zap.NewNop(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	var routes []string
+	for _, r := range s.router.Routes() {
+		routes = append(routes, r.Method+" "+r.Path)
+	}
+	slices.Sort(routes)
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	var documented []string
+	for path, methods := range spec.Paths {
+		for method := range methods {
+			documented = append(documented, strings.ToUpper(method)+" "+specParam.ReplaceAllString(path, ":$1"))
+		}
+	}
+	slices.Sort(documented)
+
+	for _, r := range routes {
+		if !slices.Contains(documented, r) {
+			t.Errorf("route %s is missing from the OpenAPI spec", r)
+		}
+	}
+	for _, d := range documented {
+		if !slices.Contains(routes, d) {
+			t.Errorf("OpenAPI operation %s has no route", d)
+		}
+	}
+}
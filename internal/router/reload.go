@@ -0,0 +1,116 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"go.uber.org/zap"
+)
+
+// Reload applies a validated config to the running server and swaps it in
+// for the handlers and background workers. Everything that can fail is done
+// before anything is changed, so on error the old config stays in effect.
+// It returns the keys that changed but only take effect after a restart.
+func (s *Server) Reload(cfg *config.Config) ([]string, error) {
+	old := s.cfg.Get()
+
+	jwtVerifier := s.jwt.Load()
+	if !reflect.DeepEqual(old.Access.JWT, cfg.Access.JWT) {
+		jwtVerifier = nil
+		if cfg.Access.JWT.Enabled() {
+			v, err := auth.NewJWTVerifier(cfg.Access.JWT, s.logs.Logger("auth"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load JWKS: %w", err)
+			}
+			jwtVerifier = v
+		}
+	}
+
+	applyTokens, err := s.tokens.PrepareReload(cfg.Access.Token)
+	if err != nil {
+		return nil, err
+	}
+	allow, err := auth.ParsePrefixes(cfg.Access.AllowedIPs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed_ips: %w", err)
+	}
+	deny, err := auth.ParsePrefixes(cfg.Access.DeniedIPs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denied_ips: %w", err)
+	}
+
+	// nothing below can fail
+	applyTokens()
+	s.ipFilter.Set(allow, deny)
+	s.jwt.Store(jwtVerifier)
+	s.cors.Store(newCORS(cfg.Server))
+	s.limits.Update(cfg.Limits)
+	s.dns.Update(cfg.DomainCheck, cfg.Cloudflare.NodeIP)
+	s.applyLogLevels(old.Logging, cfg.Logging)
+
+	s.cfg.Swap(cfg)
+
+	return restartRequired(s.started, cfg), nil
+}
+
+// applyLogLevels sets the configured levels. Runtime changes made through
+// PUT /log/level are replaced.
+func (s *Server) applyLogLevels(old, cfg config.Logging) {
+	if err := s.logs.SetLevel("", cfg.Level); err != nil {
+		s.log.Error("failed to set log level", zap.Error(err))
+	}
+	for name, level := range cfg.Subsystems {
+		if err := s.logs.SetLevel(name, level); err != nil {
+			s.log.Error("failed to set log level", zap.String("subsystem", name), zap.Error(err))
+		}
+	}
+	for name := range old.Subsystems {
+		if _, ok := cfg.Subsystems[name]; !ok {
+			s.logs.SetLevel(name, "")
+		}
+	}
+}
+
+// restartRequired lists the config keys that are only read at startup and
+// differ between the startup config and cfg.
+func restartRequired(old, cfg *config.Config) []string {
+	// client identities and log levels are applied live
+	oldTLS, newTLS := old.Server.TLS, cfg.Server.TLS
+	oldTLS.ClientIdentities, newTLS.ClientIdentities = nil, nil
+	oldLogging, newLogging := old.Logging, cfg.Logging
+	oldLogging.Level, oldLogging.Subsystems = "", nil
+	newLogging.Level, newLogging.Subsystems = "", nil
+
+	fields := []struct {
+		key      string
+		old, new any
+	}{
+		{"http_server.host", old.Server.Host, cfg.Server.Host},
+		{"http_server.port", old.Server.Port, cfg.Server.Port},
+		{"http_server.trusted_proxies", old.Server.TrustedProxies, cfg.Server.TrustedProxies},
+		{"http_server.tls", oldTLS, newTLS},
+		{"access.tokens_file", old.Access.TokensFile, cfg.Access.TokensFile},
+		{"access.lockout", old.Access.Lockout, cfg.Access.Lockout},
+		{"access.signature_max_skew", old.Access.SignatureMaxSkew, cfg.Access.SignatureMaxSkew},
+		{"state_file", old.StateFile, cfg.StateFile},
+		{"audit", old.Audit, cfg.Audit},
+		{"limits.usage_file", old.Limits.UsageFile, cfg.Limits.UsageFile},
+		{"cluster.enabled", old.Cluster.Enabled, cfg.Cluster.Enabled},
+		{"cluster.node_name", old.Cluster.NodeName, cfg.Cluster.NodeName},
+		{"maintenance.auto", old.Maintenance.Auto, cfg.Maintenance.Auto},
+		{"logging", oldLogging, newLogging},
+		{"tracing", old.Tracing, cfg.Tracing},
+		{"reload", old.Reload, cfg.Reload},
+		{"debug_mode", old.DebugMode, cfg.DebugMode},
+	}
+
+	var keys []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.old, f.new) {
+			keys = append(keys, f.key)
+		}
+	}
+	return keys
+}
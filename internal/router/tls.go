@@ -0,0 +1,139 @@
+package router
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"go.uber.org/zap"
+)
+
+// reloadCheck is how often certificate files are checked for changes.
+const reloadCheck = 10 * time.Second
+
+// certReloader serves the current server certificate and client CA bundle,
+// re-reading them from disk when their modification time changes.
+type certReloader struct {
+	cfg config.TLS
+	log *zap.Logger
+
+	mu      sync.Mutex
+	tlsCfg  *tls.Config
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(cfg config.TLS, log *zap.Logger) (*certReloader, error) {
+	r := &certReloader{cfg: cfg, log: log}
+
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.tlsCfg = tlsCfg
+	r.modTime = modTime
+	r.checked = time.Now()
+	return r, nil
+}
+
+func (r *certReloader) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < reloadCheck {
+		return r.tlsCfg, nil
+	}
+	r.checked = time.Now()
+
+	modTime, err := r.filesModTime()
+	if err != nil || !modTime.After(r.modTime) {
+		return r.tlsCfg, nil
+	}
+
+	tlsCfg, err := r.load()
+	if err != nil {
+		r.log.Error("failed to reload TLS certificates, keeping the old ones", zap.Error(err))
+		return r.tlsCfg, nil
+	}
+
+	r.tlsCfg = tlsCfg
+	r.modTime = modTime
+	r.log.Info("TLS certificates reloaded")
+	return r.tlsCfg, nil
+}
+
+func (r *certReloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client CA bundle contains no certificates")
+		}
+
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.cfg.RequireClientCert {
+			tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return tlsCfg, nil
+}
+
+// filesModTime returns the latest modification time of the configured files.
+func (r *certReloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// certIdentity maps the verified client certificate of a request to one of
+// the configured identities.
+func certIdentity(state *tls.ConnectionState, identities []config.ClientIdentity) (auth.Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return auth.Identity{}, false
+	}
+
+	leaf := state.VerifiedChains[0][0]
+	for _, ci := range identities {
+		if ci.Subject == leaf.Subject.CommonName || ci.Subject == leaf.Subject.String() {
+			return auth.Identity{Name: ci.Name, Scopes: ci.Scopes, Domains: ci.Domains}, true
+		}
+	}
+	return auth.Identity{}, false
+}
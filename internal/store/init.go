@@ -0,0 +1,224 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+// Store keeps the registry of managed proxies in a JSON file. Every write
+// re-reads the file under an exclusive flock, so several npapi nodes can share
+// one state file (e.g. on NFS) without losing each other's changes.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	proxies map[string]Proxy
+	nodes   map[string]Node
+	leader  *Lease
+}
+
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		proxies: make(map[string]Proxy),
+		nodes:   make(map[string]Node),
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload refreshes the in-memory copy from the state file.
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock(syscall.LOCK_SH)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.load()
+}
+
+func (s *Store) Get(domain string) (Proxy, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.proxies[domain]
+	return p, ok
+}
+
+func (s *Store) List() []Proxy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.list()
+}
+
+func (s *Store) Put(p Proxy) error {
+	return s.update(func() {
+		s.proxies[p.Domain] = p
+	})
+}
+
+// Create adds a proxy and fails with ErrExists if the domain is already
+// registered.
+func (s *Store) Create(p Proxy) error {
+	var exists bool
+	err := s.update(func() {
+		if _, exists = s.proxies[p.Domain]; !exists {
+			s.proxies[p.Domain] = p
+		}
+	})
+	if err == nil && exists {
+		return ErrExists
+	}
+	return err
+}
+
+// Update applies fn to the stored proxy for domain. It is a no-op if the
+// proxy no longer exists.
+func (s *Store) Update(domain string, fn func(p *Proxy)) error {
+	return s.update(func() {
+		p, ok := s.proxies[domain]
+		if !ok {
+			return
+		}
+		fn(&p)
+		s.proxies[domain] = p
+	})
+}
+
+// Claim marks a pending proxy as provisioning, so only one node activates
+// it. It returns false if the proxy is gone or was claimed by another node.
+func (s *Store) Claim(domain string) (bool, error) {
+	claimed := false
+	err := s.update(func() {
+		p, ok := s.proxies[domain]
+		if !ok || !p.Pending {
+			return
+		}
+		p.Pending, p.Provisioning = false, true
+		s.proxies[domain] = p
+		claimed = true
+	})
+	return claimed, err
+}
+
+func (s *Store) Delete(domain string) error {
+	return s.update(func() {
+		delete(s.proxies, domain)
+	})
+}
+
+func (s *Store) list() []Proxy {
+	proxies := make([]Proxy, 0, len(s.proxies))
+	for _, p := range s.proxies {
+		proxies = append(proxies, p)
+	}
+	sort.Slice(proxies, func(i, j int) bool { return proxies[i].Domain < proxies[j].Domain })
+	return proxies
+}
+
+// update runs fn against the freshest state on disk and persists the result.
+func (s *Store) update(fn func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	fn()
+	return s.save()
+}
+
+func (s *Store) lock(how int) (func(), error) {
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock state file: %w", err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	s.proxies = make(map[string]Proxy, len(st.Proxies))
+	for _, p := range st.Proxies {
+		s.proxies[p.Domain] = p
+	}
+	s.nodes = make(map[string]Node, len(st.Nodes))
+	for _, n := range st.Nodes {
+		s.nodes[n.Name] = n
+	}
+	s.leader = st.Leader
+
+	return nil
+}
+
+// save writes the state to a temporary file and renames it over the old one,
+// so a crash never leaves a half-written state file behind.
+func (s *Store) save() error {
+	st := state{
+		Proxies: s.list(),
+		Nodes:   s.nodeList(),
+		Leader:  s.leader,
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
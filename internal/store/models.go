@@ -0,0 +1,47 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrExists = errors.New("proxy_exists")
+
+type Proxy struct {
+	Domain     string    `json:"domain"`
+	Target     string    `json:"target"`
+	CertDomain string    `json:"cert_domain"`
+	ZoneID     string    `json:"zone_id,omitempty"`
+	DNSRecords []string  `json:"dns_records,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+
+	// Pending proxies wait for their custom domain to point at the node
+	// before a certificate is issued and the nginx site is created.
+	Pending bool `json:"pending,omitempty"`
+	// Provisioning is set while the API is still creating the DNS record,
+	// certificate and nginx site. Sync leaves such proxies alone.
+	Provisioning bool `json:"provisioning,omitempty"`
+	Disabled     bool `json:"disabled,omitempty"`
+	Maintenance  bool `json:"maintenance"`
+	// AutoMaintenance is set when maintenance was turned on by failed
+	// health checks, so it can be turned off again once the target recovers.
+	AutoMaintenance bool `json:"auto_maintenance,omitempty"`
+}
+
+type Node struct {
+	Name     string    `json:"name"`
+	IP       string    `json:"ip"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type Lease struct {
+	Holder string    `json:"holder"`
+	Until  time.Time `json:"until"`
+}
+
+type state struct {
+	Proxies []Proxy `json:"proxies"`
+	Nodes   []Node  `json:"nodes,omitempty"`
+	Leader  *Lease  `json:"leader,omitempty"`
+}
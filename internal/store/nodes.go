@@ -0,0 +1,55 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Heartbeat records that node is alive.
+func (s *Store) Heartbeat(name, ip string) error {
+	return s.update(func() {
+		s.nodes[name] = Node{
+			Name:     name,
+			IP:       ip,
+			LastSeen: time.Now(),
+		}
+	})
+}
+
+// HealthyNodes returns nodes that sent a heartbeat within ttl.
+func (s *Store) HealthyNodes(ttl time.Duration) []Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var nodes []Node
+	for _, n := range s.nodeList() {
+		if time.Since(n.LastSeen) <= ttl {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// AcquireLeadership takes or extends the leader lease for name. It returns
+// false while another node holds an unexpired lease.
+func (s *Store) AcquireLeadership(name string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := s.update(func() {
+		now := time.Now()
+		if s.leader != nil && s.leader.Holder != name && now.Before(s.leader.Until) {
+			return
+		}
+		s.leader = &Lease{Holder: name, Until: now.Add(ttl)}
+		acquired = true
+	})
+	return acquired, err
+}
+
+func (s *Store) nodeList() []Node {
+	nodes := make([]Node, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	stepKey   = "npa_trace_step"
+	parentKey = "npa_trace_parent"
+)
+
+// Step ends the previous step span of the request and starts a new one, so
+// a handler only has to mark where each step begins. Spans of outgoing calls
+// made with c.Request.Context() are nested under the current step.
+func Step(c *gin.Context, name string) {
+	endStep(c, 0)
+
+	value, _ := c.Get(parentKey)
+	parent, ok := value.(context.Context)
+	if !ok {
+		parent = c.Request.Context()
+		c.Set(parentKey, parent)
+	}
+
+	ctx, span := Start(parent, "step "+name)
+	c.Set(stepKey, span)
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// Steps ends the step span left open when the handler returns and marks it
+// as failed if the response is an error.
+func Steps() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		endStep(c, c.Writer.Status())
+	}
+}
+
+func endStep(c *gin.Context, status int) {
+	value, _ := c.Get(stepKey)
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	if status >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+	c.Set(stepKey, nil)
+}
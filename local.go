@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/d1manpro/nginx-proxy-api/client"
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
+	"github.com/d1manpro/nginx-proxy-api/internal/logging"
+	"github.com/d1manpro/nginx-proxy-api/internal/maintenance"
+	"github.com/d1manpro/nginx-proxy-api/internal/router"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+)
+
+// localClient builds the API in-process from the server config, so that
+// commands go through the same validation, audit and limits as the HTTP API
+// without a running server. Background workers are not started.
+func localClient() (*client.Client, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.Access.Token == "" {
+		return nil, nil, errors.New("local mode needs access.token in the config")
+	}
+
+	logCfg := cfg.Logging
+	logCfg.Level = "warn"
+	logCfg.File = ""
+	logs, err := logging.New(logCfg, os.Stderr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// a running server holds the audit log lock; the tokens and usage files
+	// are not locked, so local mode must not run next to it
+	auditLog, err := audit.Open(cfg.Audit.File, cfg.Audit.HashChain)
+	if err != nil {
+		if errors.Is(err, audit.ErrLocked) {
+			return nil, nil, errors.New("a server is running with this config, use -server instead of -local")
+		}
+		return nil, nil, err
+	}
+
+	st, err := store.Open(cfg.StateFile)
+	if err != nil {
+		auditLog.Close()
+		return nil, nil, err
+	}
+
+	tokens, err := auth.LoadTokens(cfg.Access.TokensFile, cfg.Access.Token)
+	if err != nil {
+		auditLog.Close()
+		return nil, nil, err
+	}
+
+	ipFilter, err := auth.NewIPFilter([]string{"127.0.0.1"}, nil)
+	if err != nil {
+		auditLog.Close()
+		return nil, nil, err
+	}
+
+	limiter, err := limits.New(cfg.Limits, st)
+	if err != nil {
+		auditLog.Close()
+		return nil, nil, err
+	}
+
+	current := config.NewCurrent(cfg)
+	cfAPI := cloudflare.InitCfAPI(current, logs.Logger("cloudflare"))
+	dnsChecker := dnscheck.New(cfg.DomainCheck, cfg.Cloudflare.NodeIP)
+	maint := maintenance.New(current, logs.Logger("maintenance"), st)
+	prober := health.NewProber(current, logs.Logger("health"), st)
+
+	server := router.NewServer(current, logs.Logger("api"), logs, cfAPI, st, prober, maint, tokens, ipFilter, nil, auditLog, limiter, dnsChecker)
+
+	hc := &http.Client{Transport: handlerTransport{server.Handler()}}
+	done := func() {
+		auditLog.Close()
+		logs.Logger("").Sync()
+	}
+	return client.New("http://local", client.WithToken(cfg.Access.Token), client.WithHTTPClient(hc)), done, nil
+}
+
+// handlerTransport serves requests with an http.Handler instead of the
+// network.
+type handlerTransport struct {
+	h http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.RemoteAddr = "127.0.0.1:0"
+	rec := httptest.NewRecorder()
+	t.h.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/router"
+	"go.uber.org/zap"
+)
+
+// reloadConfig loads the config and templates again and applies them to the
+// running server. A config that fails validation is logged and the current
+// one stays in effect.
+func reloadConfig(log *zap.Logger, server *router.Server) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Error("failed to reload config, keeping the current one", zap.Error(err))
+		return
+	}
+
+	restart, err := server.Reload(cfg)
+	if err != nil {
+		log.Error("failed to apply config, keeping the current one", zap.Error(err))
+		return
+	}
+
+	log.Info("Config reloaded")
+	if len(restart) > 0 {
+		log.Warn("some changes take effect only after a restart", zap.Strings("keys", restart))
+	}
+}
+
+// watchConfig checks the config, template and .env files every interval and
+// sends on the returned channel when one of them was modified.
+func watchConfig(cfg *config.Config, stop <-chan struct{}) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+
+	cfgPath, tmplPath := config.Paths()
+	paths := []string{cfgPath, tmplPath, config.EnvFile()}
+	if cfg.Maintenance.Template != "" {
+		paths = append(paths, cfg.Maintenance.Template)
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Reload.Interval)
+		defer ticker.Stop()
+
+		last := modTime(paths)
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+
+			if t := modTime(paths); t.After(last) {
+				last = t
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changed
+}
+
+// modTime returns the latest modification time of paths. Missing files are
+// skipped; the reload reports them.
+func modTime(paths []string) time.Time {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
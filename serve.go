@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/d1manpro/nginx-proxy-api/internal/audit"
+	"github.com/d1manpro/nginx-proxy-api/internal/auth"
+	"github.com/d1manpro/nginx-proxy-api/internal/cloudflare"
+	"github.com/d1manpro/nginx-proxy-api/internal/cluster"
+	"github.com/d1manpro/nginx-proxy-api/internal/config"
+	"github.com/d1manpro/nginx-proxy-api/internal/dnscheck"
+	"github.com/d1manpro/nginx-proxy-api/internal/health"
+	"github.com/d1manpro/nginx-proxy-api/internal/limits"
+	"github.com/d1manpro/nginx-proxy-api/internal/logging"
+	"github.com/d1manpro/nginx-proxy-api/internal/maintenance"
+	"github.com/d1manpro/nginx-proxy-api/internal/onboarding"
+	"github.com/d1manpro/nginx-proxy-api/internal/router"
+	"github.com/d1manpro/nginx-proxy-api/internal/store"
+	"github.com/d1manpro/nginx-proxy-api/internal/tracing"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// serve runs the API server with the background workers until SIGINT or
+// SIGTERM.
+func serve() {
+	log := setupLogger()
+	defer log.Sync()
+	log.Info("Starting...\n")
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("failed to load config", zap.Error(err))
+	}
+
+	logs, err := logging.New(cfg.Logging, os.Stdout)
+	if err != nil {
+		log.Fatal("failed to setup logging", zap.Error(err))
+	}
+	log = logs.Logger("")
+	defer log.Sync()
+
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		log.Fatal("failed to setup tracing", zap.Error(err))
+	}
+
+	current := config.NewCurrent(cfg)
+
+	cfAPI := cloudflare.InitCfAPI(current, logs.Logger("cloudflare"))
+
+	st, err := store.Open(cfg.StateFile)
+	if err != nil {
+		log.Fatal("failed to load proxy state", zap.Error(err))
+	}
+
+	tokens, err := auth.LoadTokens(cfg.Access.TokensFile, cfg.Access.Token)
+	if err != nil {
+		log.Fatal("failed to load api tokens", zap.Error(err))
+	}
+
+	ipFilter, err := auth.NewIPFilter(cfg.Access.AllowedIPs, cfg.Access.DeniedIPs)
+	if err != nil {
+		log.Fatal("failed to load ip lists", zap.Error(err))
+	}
+
+	var jwtVerifier *auth.JWTVerifier
+	if cfg.Access.JWT.Enabled() {
+		jwtVerifier, err = auth.NewJWTVerifier(cfg.Access.JWT, logs.Logger("auth"))
+		if err != nil {
+			log.Fatal("failed to load JWKS", zap.Error(err))
+		}
+	}
+
+	auditLog, err := audit.Open(cfg.Audit.File, cfg.Audit.HashChain)
+	if err != nil {
+		log.Fatal("failed to open audit log", zap.Error(err))
+	}
+	defer auditLog.Close()
+
+	limiter, err := limits.New(cfg.Limits, st)
+	if err != nil {
+		log.Fatal("failed to load usage", zap.Error(err))
+	}
+
+	dnsChecker := dnscheck.New(cfg.DomainCheck, cfg.Cloudflare.NodeIP)
+
+	maint := maintenance.New(current, logs.Logger("maintenance"), st)
+
+	prober := health.NewProber(current, logs.Logger("health"), st)
+	if cfg.Maintenance.Auto {
+		prober.OnResult(maint.HandleHealth)
+	}
+	prober.Start()
+
+	server := router.NewServer(current, logs.Logger("api"), logs, cfAPI, st, prober, maint, tokens, ipFilter, jwtVerifier, auditLog, limiter, dnsChecker)
+	server.Start()
+
+	onboard := onboarding.New(current, logs.Logger("onboarding"), st, dnsChecker, limiter, tokens)
+	onboard.Start()
+
+	var cl *cluster.Cluster
+	if cfg.Cluster.Enabled {
+		cl = cluster.New(current, logs.Logger("cluster"), cfAPI, st, maint)
+		cl.Start()
+	}
+
+	stopWatch := make(chan struct{})
+	var watch <-chan struct{}
+	if cfg.Reload.Watch {
+		watch = watchConfig(cfg, stopWatch)
+	}
+
+	waitForShutdown(log, server, watch)
+	close(stopWatch)
+
+	server.Stop()
+	log.Info("HTTP-server stopped")
+
+	if cl != nil {
+		cl.Stop()
+		log.Info("Cluster sync stopped")
+	}
+
+	onboard.Stop()
+	log.Info("Onboarding worker stopped")
+
+	prober.Stop()
+	log.Info("Health checks stopped")
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error("failed to flush traces", zap.Error(err))
+	}
+
+	log.Info("Script stopped")
+}
+
+// waitForShutdown blocks until SIGINT or SIGTERM. SIGHUP and changes
+// reported on watch reload the config.
+func waitForShutdown(log *zap.Logger, server *router.Server, watch <-chan struct{}) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-hup:
+			log.Info("SIGHUP received, reloading config")
+			reloadConfig(log, server)
+		case <-watch:
+			log.Info("Config files changed, reloading config")
+			reloadConfig(log, server)
+		}
+	}
+}
+
+func setupLogger() *zap.Logger {
+	encoderCfg := zapcore.EncoderConfig{
+		TimeKey:     "time",
+		LevelKey:    "level",
+		MessageKey:  "msg",
+		EncodeTime:  zapcore.TimeEncoderOfLayout("2006.01.02 15:04:05.000"),
+		EncodeLevel: zapcore.CapitalLevelEncoder,
+	}
+
+	return zap.New(zapcore.NewTee(zapcore.NewCore(zapcore.NewConsoleEncoder(encoderCfg), zapcore.AddSync(os.Stdout), zapcore.InfoLevel)))
+}
@@ -0,0 +1,38 @@
+// Package signature holds the request signing scheme shared by the API
+// server and its Go client. It depends on the standard library only, so the
+// client does not pull in the server.
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Scheme is the Authorization scheme of signed requests:
+//
+//	Authorization: NPA-HMAC-SHA256 Credential=<token name>, Signature=<hex>
+//	X-NPA-Timestamp: <unix seconds>
+//	X-NPA-Nonce: <random string, unique per request>
+//
+// The signature is HMAC-SHA256 over StringToSign, keyed with the token secret.
+const Scheme = "NPA-HMAC-SHA256"
+
+const (
+	HeaderTimestamp = "X-NPA-Timestamp"
+	HeaderNonce     = "X-NPA-Nonce"
+)
+
+// StringToSign joins the signed parts of a request with newlines: method,
+// request URI (path and query), timestamp, nonce and hex SHA-256 of the body.
+func StringToSign(method, uri, timestamp, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{method, uri, timestamp, nonce, hex.EncodeToString(sum[:])}, "\n")
+}
+
+func Sign(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}